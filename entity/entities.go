@@ -189,7 +189,8 @@ func (m *Manager) IterFilter(components []Component, start int) func() (*Entity,
 /// ... Basic Components ...
 /////////////////////////////////////////////////
 
-//PositionComponent is responsible for the position of the entity
+// PositionComponent is responsible for the position of the entity.
+// The position of physics bodies is updated by the physics system every tick
 type PositionComponent struct {
 	Pos *sdl.Point
 	Z   float32
@@ -197,13 +198,63 @@ type PositionComponent struct {
 
 // PhysicsComponent is responsible for some of the physics
 type PhysicsComponent struct {
+	// FuturePos is the simulated position of the body in the current tick
 	FuturePos *math.FPoint // TODO: move this to PositionComponent
-	// velocity
+	// PrevPos is the simulated position in the previous tick. The render interpolates between PrevPos and FuturePos
+	PrevPos *math.FPoint
+	// velocity in units per second
 	Vel *math.FPoint
-	// acceleration
+	// acceleration in units per second squared
 	Acc *math.FPoint
+	// BodyType defines how the body is simulated (BodyDynamic, BodyKinematic or BodyStatic)
+	BodyType int
+	// Mass of the body. A dynamic body without mass is simulated with mass 1
+	Mass float32
+	// InvMass is the inverse of the mass. It is calculated by the physics system.
+	// Static and kinematic bodies have InvMass 0, which means infinite mass
+	InvMass float32
+	// Restitution is how much the body bounces. 0 doesn't bounce, 1 is a perfect elastic collision
+	Restitution float32
+	// Friction coefficient used when the body slides against another body
+	Friction float32
+	// LinearDamping reduces the velocity over time. 0 means no damping
+	LinearDamping float32
+	// Force accumulated during the tick. It is applied to dynamic bodies and cleared by the physics system
+	Force math.FPoint
+	// FutureAngle is the simulated angle of the body in degrees. The physics system starts it with the angle of the
+	// render component
+	FutureAngle float64
+	// AngleInitialized is set by the physics system once FutureAngle has the starting angle
+	AngleInitialized bool
+	// PrevAngle is the simulated angle in the previous tick. It is used to interpolate the rendered angle
+	PrevAngle float64
+	// AngularVel is the angular velocity in degrees per second
+	AngularVel float64
+	// AngularAcc is the angular acceleration in degrees per second squared
+	AngularAcc float64
+	// Torque accumulated during the tick. It is applied to dynamic bodies and cleared by the physics system
+	Torque float64
+	// Inertia is the moment of inertia. A dynamic body without inertia is simulated with inertia 1
+	Inertia float64
+	// InvInertia is the inverse of the moment of inertia. It is calculated by the physics system
+	InvInertia float64
+	// AngularDamping reduces the angular velocity over time. 0 means no damping
+	AngularDamping float64
+	// FixedRotation disables the angular motion, so the body is drawn and collides with the angle of the render
+	// component. Otherwise the physics owns the angle after the first tick
+	FixedRotation bool
 }
 
+const (
+	// BodyDynamic is the BodyType constant for bodies moved by velocity, forces and collisions
+	BodyDynamic = iota
+	// BodyKinematic is the BodyType constant for bodies moved only by their velocity.
+	// They push dynamic bodies, but are not pushed back
+	BodyKinematic
+	// BodyStatic is the BodyType constant for bodies that never move
+	BodyStatic
+)
+
 // RenderComponent is responsible for the rendering of the entity
 type RenderComponent struct {
 	Texture    *sdl.Texture
@@ -212,6 +263,11 @@ type RenderComponent struct {
 	Center     *sdl.Point
 	Flip       sdl.RendererFlip
 	RenderType int
+	// Layer is the render layer. The layers are drawn from the lowest to the highest. In the same layer,
+	// the entities with higher Z (farther) are drawn first. The entities with the same layer and Z are drawn in
+	// the order of their slots in the entity manager, so a new entity reusing a freed slot may be drawn before
+	// older ones. Set the layer or Z of the entities that must be drawn on top
+	Layer int
 }
 
 const (
@@ -225,11 +281,35 @@ const (
 	RTGrid
 )
 
-// CameraComponent is responsible to render only the content of the viewport
+// CameraComponent is responsible to render only the content of the viewport.
+// The render system draws all the active cameras, so the screen can be split between them
 type CameraComponent struct {
 	ViewportSize sdl.Point
 	WorldSize    sdl.Point
 	IsActive     bool
+	// Viewport is the part of the screen where the camera is drawn. If it's nil, the camera uses the whole screen
+	Viewport *sdl.Rect
+	// Order is the order the cameras are drawn. The cameras with higher order are drawn on top (e.g. HUD)
+	Order int
+	// LayerMask has a bit for each render layer drawn by the camera (1 << layer). If it's 0, all layers are drawn
+	LayerMask uint32
+}
+
+// ViewSize returns the size of the world seen by the camera. It's the viewport size or, if it's not set, the size
+// of the viewport on the screen
+func (c *CameraComponent) ViewSize() sdl.Point {
+	if c.ViewportSize.X == 0 && c.ViewportSize.Y == 0 && c.Viewport != nil {
+		return sdl.Point{X: c.Viewport.W, Y: c.Viewport.H}
+	}
+	return c.ViewportSize
+}
+
+// SeesLayer checks if the render layer is in the layer mask of the camera
+func (c *CameraComponent) SeesLayer(layer int) bool {
+	if c.LayerMask == 0 {
+		return true
+	}
+	return layer >= 0 && layer < 32 && c.LayerMask&(1<<uint(layer)) != 0
 }
 
 // AnimationComponent is responsible for animate the entity
@@ -264,6 +344,222 @@ type CollisionComponent struct {
 	// CollisionAreas contains the rectangles that will be checked.
 	// The position is relative to the upper left corner of the renderer
 	CollisionAreas []sdl.Rect
+	// Continuous enables swept collision checks between the current position and the future position,
+	// so fast objects don't go through thin colliders
+	Continuous bool
+	// OneWay makes the collider block characters only when they are falling on it from above
+	OneWay bool
+	// Layer is the collision layer (a single bit) of the collider. See RegisterCollisionLayer to name the layers.
+	// If it's 0, the collider is in the default layer
+	Layer uint32
+	// Mask has the layers the collider collides with. If it's 0, the collider collides with all the layers
+	Mask uint32
+	// Shapes are checked in addition to the collision areas. They are rotated with the entity: by the physics angle
+	// or, without physics (or with FixedRotation), by the render angle. The rotation is around the center of
+	// the render component (RenderComponent.Center or the center of the crop), or the entity position without it
+	Shapes []CollisionShape
+	// Sensor makes the collider a trigger volume. It notifies when other colliders enter and exit it, but they
+	// aren't stopped by it (continuous collisions, character controllers and the collision response handlers)
+	Sensor bool
+	// IgnoreZ makes the sensor detect colliders in any Z plane
+	IgnoreZ bool
+	// PixelMask refines the collisions of the collision areas: they only collide where the masks have solid
+	// pixels. The mask starts at the entity position, like the sprite, and is flipped with it
+	// (RenderComponent.Flip). See Spritesheet.LoadMask.
+	// Only the collision areas use the mask: the shapes, the continuous collisions and the tile maps ignore it
+	PixelMask *PixelMask
+}
+
+const (
+	// ShapeCircle is a circle with center Offset and radius Radius
+	ShapeCircle = iota
+	// ShapeCapsule is a vertical capsule with center Offset and radius Radius.
+	// Length is the distance between the centers of its two half circles
+	ShapeCapsule
+	// ShapePolygon is a convex polygon with the vertices Points, relative to Offset
+	ShapePolygon
+	// ShapeBox is a box with center Offset and size Size. Unlike the collision areas, it can be rotated
+	ShapeBox
+)
+
+// CollisionShape is a shape used to check collisions. The position is relative to the entity position
+type CollisionShape struct {
+	ShapeType int
+	Offset    math.FPoint
+	Radius    float32
+	Length    float32
+	Size      math.FPoint
+	Points    []math.FPoint
+}
+
+// CollidesWith checks if the layer of each collider is in the mask of the other one
+func (c *CollisionComponent) CollidesWith(other *CollisionComponent) bool {
+	return c.layer()&other.mask() != 0 && other.layer()&c.mask() != 0
+}
+
+// layer returns the collision layer, using the default one if it's not set
+func (c *CollisionComponent) layer() uint32 {
+	if c.Layer == 0 {
+		return DefaultCollisionLayer
+	}
+	return c.Layer
+}
+
+// mask returns the collision mask, using all the layers if it's not set
+func (c *CollisionComponent) mask() uint32 {
+	if c.Mask == 0 {
+		return AllCollisionLayers
+	}
+	return c.Mask
+}
+
+const (
+	// TileEmpty is a tile that doesn't collide
+	TileEmpty = iota
+	// TileFull is a solid tile
+	TileFull
+	// TileSlopeUp is a slope rising to the right. The bottom right half of the tile is solid
+	TileSlopeUp
+	// TileSlopeDown is a slope rising to the left. The bottom left half of the tile is solid
+	TileSlopeDown
+	// TileOneWay is a tile that only blocks colliders coming from above
+	TileOneWay
+)
+
+// TileCollisionComponent is a grid of tiles checked by the collision system, so tile based levels don't need
+// one collider per tile. The grid starts at the entity position
+type TileCollisionComponent struct {
+	// TileSize is the width and height of each tile
+	TileSize sdl.Point
+	// Tiles has the type of each tile (TileEmpty, TileFull, TileSlopeUp, TileSlopeDown or TileOneWay), by row:
+	// Tiles[row][col]
+	Tiles [][]int
+	// Layer and Mask work like the ones of the collision component
+	Layer uint32
+	Mask  uint32
+}
+
+// Tile returns the type of the tile. Tiles outside of the grid are empty
+func (t *TileCollisionComponent) Tile(col, row int) int {
+	if row < 0 || row >= len(t.Tiles) || col < 0 || col >= len(t.Tiles[row]) {
+		return TileEmpty
+	}
+	return t.Tiles[row][col]
+}
+
+// CollidesWith checks if the tiles and the collider collide with each other, according to their layers and masks
+func (t *TileCollisionComponent) CollidesWith(other *CollisionComponent) bool {
+	return (&CollisionComponent{Layer: t.Layer, Mask: t.Mask}).CollidesWith(other)
+}
+
+// ForceFieldComponent applies forces to the physics bodies that overlap its area
+type ForceFieldComponent struct {
+	// Area of the field. The position is relative to the entity position
+	Area      sdl.Rect
+	FieldType int
+	// Force applied by directional fields (e.g. wind)
+	Force math.FPoint
+	// Strength of radial fields. Positive values attract the bodies to the center of the area, negative values repel
+	Strength float32
+	// Drag is how much a drag zone (e.g. water) slows down the bodies. The higher, the slower
+	Drag float32
+}
+
+const (
+	// FieldDirectional is the FieldType constant for fields that apply the same force everywhere in the area
+	FieldDirectional = iota
+	// FieldRadial is the FieldType constant for fields that attract or repel bodies from the center of the area
+	FieldRadial
+	// FieldDrag is the FieldType constant for fields that apply a force opposed to the velocity of the bodies
+	FieldDrag
+)
+
+// JointComponent connects two entities with physics component. The joint itself is an entity,
+// so it can be created and deleted like any other entity
+type JointComponent struct {
+	BodyA     *Entity
+	BodyB     *Entity
+	JointType int
+	// AnchorA and AnchorB are the points where the joint is attached. They are relative to the position of
+	// each body and rotate with it
+	AnchorA math.FPoint
+	AnchorB math.FPoint
+	// Length is the distance kept by distance joints, the rest length of springs and the maximum length of ropes
+	Length float32
+	// Stiffness of spring joints
+	Stiffness float32
+	// Damping of spring joints
+	Damping float32
+	// BreakForce is the force needed to break the joint. 0 means the joint never breaks
+	BreakForce float32
+	// Broken is set when the joint breaks. Broken joints are ignored by the physics system
+	Broken bool
+}
+
+const (
+	// JointDistance is the JointType constant for joints that keep the anchors at a fixed distance
+	JointDistance = iota
+	// JointSpring is the JointType constant for springs pulling the anchors to the rest length
+	JointSpring
+	// JointPin is the JointType constant for revolute joints. The anchors are kept together,
+	// but the bodies can rotate around them
+	JointPin
+	// JointRope is the JointType constant for joints that keep the anchors up to a maximum distance
+	JointRope
+)
+
+// CharacterControllerComponent makes the entity move like a platformer character, sliding against solid colliders.
+// The entity also needs position, physics and collision components
+type CharacterControllerComponent struct {
+	// JumpSpeed is the upward speed, in units per second, the character has when it jumps
+	JumpSpeed float32
+	// CoyoteTime is how long, in seconds, the character can still jump after leaving the ground
+	CoyoteTime float32
+	// JumpBuffer is how long, in seconds, a jump request is kept while the character can't jump
+	JumpBuffer float32
+	// Contacts has the sides touched in the last update (ContactBelow, ContactAbove, ContactLeft and ContactRight)
+	Contacts int
+	// Ground is the entity the character is standing on. Characters are carried by moving grounds
+	Ground *Entity
+	// CoyoteTimer is the time left to jump after leaving the ground
+	CoyoteTimer float32
+	// JumpRequested is set by Jump and cleared when the character jumps or the jump buffer expires
+	JumpRequested bool
+	// JumpTimer is the time left before the jump request expires
+	JumpTimer float32
+}
+
+const (
+	// ContactBelow is set in the Contacts of the character when it is standing on a collider
+	ContactBelow = 1 << iota
+	// ContactAbove is set in the Contacts of the character when it hits a ceiling
+	ContactAbove
+	// ContactLeft is set in the Contacts of the character when it touches a wall on its left
+	ContactLeft
+	// ContactRight is set in the Contacts of the character when it touches a wall on its right
+	ContactRight
+)
+
+// IsGrounded returns whether the character is standing on a collider
+func (c *CharacterControllerComponent) IsGrounded() bool {
+	return c.Contacts&ContactBelow != 0
+}
+
+// OnWall returns whether the character is touching a wall
+func (c *CharacterControllerComponent) OnWall() bool {
+	return c.Contacts&(ContactLeft|ContactRight) != 0
+}
+
+// OnCeiling returns whether the character hit a ceiling
+func (c *CharacterControllerComponent) OnCeiling() bool {
+	return c.Contacts&ContactAbove != 0
+}
+
+// Jump requests the character to jump. It jumps as soon as it is on the ground or within the coyote time.
+// If it can't jump, the request is kept for JumpBuffer seconds
+func (c *CharacterControllerComponent) Jump() {
+	c.JumpRequested = true
+	c.JumpTimer = c.JumpBuffer
 }
 
 // GridComponent is used for debugging
@@ -2,6 +2,8 @@ package entity
 
 import (
 	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
 )
 
 func TestManager_CreateIncreaseID(t *testing.T) {
@@ -257,6 +259,27 @@ func TestBinarySearchInsert(t *testing.T) {
 	}
 }
 
+func TestCameraComponent(t *testing.T) {
+	camera := &CameraComponent{Viewport: &sdl.Rect{X: 400, Y: 0, W: 400, H: 600}}
+	if size := camera.ViewSize(); size != (sdl.Point{X: 400, Y: 600}) {
+		t.Errorf("view size is %v; want the size of the viewport", size)
+	}
+	camera.ViewportSize = sdl.Point{X: 800, Y: 1200}
+	if size := camera.ViewSize(); size != camera.ViewportSize {
+		t.Errorf("view size is %v; want %v", size, camera.ViewportSize)
+	}
+
+	if !camera.SeesLayer(5) {
+		t.Error("cameras without layer mask should draw all layers")
+	}
+	camera.LayerMask = 1<<0 | 1<<2
+	for layer, want := range map[int]bool{0: true, 1: false, 2: true, -1: false, 40: false} {
+		if got := camera.SeesLayer(layer); got != want {
+			t.Errorf("SeesLayer(%d) = %v; want %v", layer, got, want)
+		}
+	}
+}
+
 func BenchmarkBinSearchInsert(b *testing.B) {
 	// run the Fib function b.N times
 	arr := []uint16{1, 5, 6, 7, 8, 9}
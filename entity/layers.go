@@ -0,0 +1,51 @@
+package entity
+
+import (
+	"github.com/tubelz/macaw/internal/utils"
+)
+
+const (
+	// DefaultCollisionLayer is the layer of the colliders without a layer
+	DefaultCollisionLayer uint32 = 1
+	// AllCollisionLayers is the mask of the colliders without a mask
+	AllCollisionLayers uint32 = 0xFFFFFFFF
+)
+
+// collisionLayers has the bit of each named collision layer
+var collisionLayers = map[string]uint32{"default": DefaultCollisionLayer}
+
+// RegisterCollisionLayer gives a name to the next free collision layer and returns it.
+// If the name is already registered its layer is returned. There are at most 32 layers, including the default one.
+// The layers are shared by the whole game and the registration is not safe for concurrent use: register them
+// when the game starts
+func RegisterCollisionLayer(name string) uint32 {
+	if layer, ok := collisionLayers[name]; ok {
+		return layer
+	}
+	if len(collisionLayers) >= 32 {
+		utils.LogFatalf("Unable to register collision layer **%s**. All 32 layers are being used", name)
+		return 0
+	}
+	layer := uint32(1) << uint(len(collisionLayers))
+	collisionLayers[name] = layer
+	return layer
+}
+
+// CollisionLayer returns the layer registered with the given name
+func CollisionLayer(name string) uint32 {
+	layer, ok := collisionLayers[name]
+	if !ok {
+		utils.LogFatalf("Collision layer **%s** is not registered", name)
+	}
+	return layer
+}
+
+// CollisionLayers returns the combination of the layers registered with the given names.
+// It can be used to create masks, e.g. CollisionLayers("enemy", "terrain")
+func CollisionLayers(names ...string) uint32 {
+	var layers uint32
+	for _, name := range names {
+		layers |= CollisionLayer(name)
+	}
+	return layers
+}
@@ -0,0 +1,46 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestRegisterCollisionLayer(t *testing.T) {
+	player := RegisterCollisionLayer("test player")
+	enemy := RegisterCollisionLayer("test enemy")
+	if player == 0 || enemy == 0 || player == enemy || player == DefaultCollisionLayer {
+		t.Fatalf("layers player %b and enemy %b should be different bits", player, enemy)
+	}
+	if again := RegisterCollisionLayer("test player"); again != player {
+		t.Errorf("RegisterCollisionLayer again == %b; want %b", again, player)
+	}
+	if layers := CollisionLayers("test player", "test enemy"); layers != player|enemy {
+		t.Errorf("CollisionLayers() == %b; want %b", layers, player|enemy)
+	}
+}
+
+func TestCollisionComponent_CollidesWith(t *testing.T) {
+	player := RegisterCollisionLayer("test player")
+	bullet := RegisterCollisionLayer("test bullet")
+	enemy := RegisterCollisionLayer("test enemy")
+	cases := []struct {
+		name string
+		c1   CollisionComponent
+		c2   CollisionComponent
+		want bool
+	}{
+		{"defaults", CollisionComponent{}, CollisionComponent{}, true},
+		{"layer with default", CollisionComponent{Layer: player}, CollisionComponent{}, true},
+		{"bullet hits enemy", CollisionComponent{Layer: bullet, Mask: enemy}, CollisionComponent{Layer: enemy}, true},
+		{"bullet ignores player", CollisionComponent{Layer: bullet, Mask: enemy}, CollisionComponent{Layer: player},
+			false},
+		{"one way mask", CollisionComponent{Layer: player}, CollisionComponent{Layer: enemy, Mask: enemy}, false},
+	}
+	for _, c := range cases {
+		if got := c.c1.CollidesWith(&c.c2); got != c.want {
+			t.Errorf("%s: CollidesWith() == %v; want %v", c.name, got, c.want)
+		}
+		if got := c.c2.CollidesWith(&c.c1); got != c.want {
+			t.Errorf("%s: CollidesWith() reversed == %v; want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package entity
+
+// PixelMask has the solid pixels of a sprite, one bit per pixel
+type PixelMask struct {
+	W, H int32
+	// stride is the number of words of each row
+	stride int32
+	bits   []uint64
+}
+
+// NewPixelMask creates a mask of the given size without solid pixels
+func NewPixelMask(w, h int32) *PixelMask {
+	stride := (w + 63) / 64
+	return &PixelMask{W: w, H: h, stride: stride, bits: make([]uint64, stride*h)}
+}
+
+// Set sets if the pixel is solid. Pixels outside of the mask are ignored
+func (m *PixelMask) Set(x, y int32, solid bool) {
+	if x < 0 || y < 0 || x >= m.W || y >= m.H {
+		return
+	}
+	word, bit := y*m.stride+x/64, uint(x%64)
+	if solid {
+		m.bits[word] |= 1 << bit
+	} else {
+		m.bits[word] &^= 1 << bit
+	}
+}
+
+// Solid checks if the pixel is solid. Pixels outside of the mask are not solid
+func (m *PixelMask) Solid(x, y int32) bool {
+	if x < 0 || y < 0 || x >= m.W || y >= m.H {
+		return false
+	}
+	return m.bits[y*m.stride+x/64]&(1<<uint(x%64)) != 0
+}
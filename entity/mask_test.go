@@ -0,0 +1,33 @@
+package entity
+
+import (
+	"testing"
+)
+
+func TestPixelMask(t *testing.T) {
+	mask := NewPixelMask(70, 2)
+	mask.Set(0, 0, true)
+	mask.Set(65, 1, true)
+	mask.Set(69, 1, true)
+	mask.Set(69, 1, false)
+	// outside of the mask
+	mask.Set(70, 0, true)
+
+	cases := []struct {
+		x, y int32
+		want bool
+	}{
+		{0, 0, true},
+		{1, 0, false},
+		{65, 1, true},
+		{65, 0, false},
+		{69, 1, false},
+		{70, 0, false},
+		{-1, 0, false},
+	}
+	for _, c := range cases {
+		if got := mask.Solid(c.x, c.y); got != c.want {
+			t.Errorf("Solid(%d, %d) == %v; want %v", c.x, c.y, got, c.want)
+		}
+	}
+}
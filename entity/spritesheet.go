@@ -13,6 +13,12 @@ type Spritesheet struct {
 	Renderer *sdl.Renderer
 	Texture  *sdl.Texture
 	Filepath string
+	// KeepAlpha keeps the alpha channel of the image after loading it, so pixel masks can be created (LoadMask)
+	KeepAlpha bool
+	// AlphaThreshold is the alpha above which the pixels are solid in the pixel masks
+	AlphaThreshold uint8
+	alpha          []uint8
+	w, h           int32
 }
 
 // Init initialize the spritesheet. It generates the texture of that image
@@ -37,11 +43,53 @@ func (s *Spritesheet) Init() {
 		if err != nil {
 			utils.LogFatalf("Unable to create texture from %s! SDL Error: %s\n", s.Filepath, sdl.GetError())
 		}
+		if s.KeepAlpha {
+			s.loadAlpha(newSurface)
+		}
 	}
 	// set values on the struct
 	s.Texture = newTexture
 }
 
+// loadAlpha keeps the alpha channel of the surface
+func (s *Spritesheet) loadAlpha(surface *sdl.Surface) {
+	// the surface is converted, so the alpha is always the fourth byte of each pixel
+	rgba, err := surface.ConvertFormat(uint32(sdl.PIXELFORMAT_RGBA32), 0)
+	if err != nil {
+		utils.LogFatalf("Unable to read the alpha channel of %s! SDL Error: %s\n", s.Filepath, sdl.GetError())
+		return
+	}
+	defer rgba.Free()
+	rgba.Lock()
+	defer rgba.Unlock()
+	pixels := rgba.Pixels()
+	s.w, s.h = rgba.W, rgba.H
+	s.alpha = make([]uint8, s.w*s.h)
+	for y := int32(0); y < s.h; y++ {
+		for x := int32(0); x < s.w; x++ {
+			s.alpha[y*s.w+x] = pixels[y*rgba.Pitch+x*4+3]
+		}
+	}
+}
+
+// LoadMask creates the pixel mask of the sprite. The spritesheet must keep the alpha channel (KeepAlpha)
+func (s *Spritesheet) LoadMask(crop *sdl.Rect) *PixelMask {
+	if s.alpha == nil {
+		utils.LogFatalf("Unable to load mask from %s. The spritesheet must have KeepAlpha set", s.Filepath)
+		return nil
+	}
+	mask := NewPixelMask(crop.W, crop.H)
+	for y := int32(0); y < crop.H; y++ {
+		for x := int32(0); x < crop.W; x++ {
+			px, py := crop.X+x, crop.Y+y
+			if px >= 0 && py >= 0 && px < s.w && py < s.h && s.alpha[py*s.w+px] > s.AlphaThreshold {
+				mask.Set(x, y, true)
+			}
+		}
+	}
+	return mask
+}
+
 // LoadSprite add the information of the sprite to the render component
 func (s *Spritesheet) LoadSprite(crop *sdl.Rect) RenderComponent {
 	sprite := RenderComponent{Texture: s.Texture, Crop: crop}
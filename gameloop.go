@@ -14,6 +14,12 @@ import (
 type GameLoop struct {
 	InputManager *input.Manager
 	SceneManager
+	// TicksPerSecond is the fixed number of updates per second. If it's not set, system.DefaultTicksPerSecond is used
+	TicksPerSecond uint32
+	// Recorder records the events of the update systems and the event bus. It's created in debug mode (-debug)
+	Recorder *system.EventRecorder
+	// recorded is the scene whose systems have the recorder
+	recorded *Scene
 	now      uint32
 	nextTick uint32
 	fps      uint32
@@ -21,13 +27,49 @@ type GameLoop struct {
 
 // update game systems that can be updated every couple frames
 func (g *GameLoop) gameUpdate() {
+	if g.Recorder != nil {
+		g.Recorder.NextTick()
+		// the recorder is attached once per scene, when it becomes the current one
+		if g.recorded != g.Current() {
+			g.attachRecorder()
+		}
+	}
 	for _, system := range g.Current().UpdateSystems {
 		system.Update()
 	}
+	// the events queued by the systems are dispatched after all of them are updated
+	if g.EventBus != nil {
+		g.EventBus.Flush()
+	}
 	g.InputManager.PopEvent()
 	g.InputManager.Mouse.ClearMouseEvent()
 }
 
+// attachRecorder sets the recorder in the event bus and in the update systems of the current scene
+func (g *GameLoop) attachRecorder() {
+	if g.EventBus != nil {
+		g.EventBus.SetRecorder(g.Recorder)
+	}
+	for _, s := range g.Current().UpdateSystems {
+		// the systems embedding system.Subject
+		if recordable, ok := s.(interface {
+			SetRecorder(*system.EventRecorder)
+		}); ok {
+			recordable.SetRecorder(g.Recorder)
+		}
+	}
+	g.recorded = g.Current()
+}
+
+// DumpEvents writes the events recorded in the last ticks to the file. It does nothing without a recorder,
+// which is created in debug mode (-debug)
+func (g *GameLoop) DumpEvents(path string, ticks int) error {
+	if g.Recorder == nil {
+		return nil
+	}
+	return g.Recorder.DumpFile(path, ticks)
+}
+
 // make it be generic like game update
 func (g *GameLoop) render() {
 	current := g.Current()
@@ -49,6 +91,10 @@ func (g *GameLoop) Run() {
 	if g.Current() == nil {
 		utils.LogFatal("You need to add at least one scene")
 	}
+	system.SetTicksPerSecond(g.TicksPerSecond)
+	if cmd.Parser.Debug() && g.Recorder == nil {
+		g.Recorder = &system.EventRecorder{}
+	}
 	fpsTick := sdl.GetTicks()
 	g.nextTick = fpsTick
 	for running := true; running; {
@@ -52,3 +52,65 @@ func TestGameLoop_Run(t *testing.T) {
 
 	utils.TeardownLog()
 }
+
+// TestGameLoop_FlushEventBus checks if the events queued by the systems are dispatched after the update
+func TestGameLoop_FlushEventBus(t *testing.T) {
+	scene := &Scene{Name: "game", RenderSystem: &system.RenderSystem{}}
+	gameLoop := &GameLoop{InputManager: &input.Manager{}}
+	gameLoop.AddScene(scene)
+	collisionSystem := &system.CollisionSystem{EntityManager: &entity.Manager{}}
+	collisionSystem.SetEventBus(gameLoop.EventBus, system.DispatchQueued)
+	dispatched := false
+	scene.AddGameUpdateSystem(&utils.MockSystem{MockFunc: func() {
+		collisionSystem.NotifyEvent(&system.BorderEvent{Side: "top"})
+	}})
+	scene.AddGameUpdateSystem(&utils.MockSystem{MockFunc: func() {
+		if dispatched {
+			t.Error("Event dispatched before the systems were updated")
+		}
+	}})
+	gameLoop.EventBus.Subscribe(&system.BorderEvent{}, func(e system.Event) {
+		dispatched = true
+	})
+
+	gameLoop.gameUpdate()
+
+	if !dispatched {
+		t.Errorf("Event dispatched == %v; want true", dispatched)
+	}
+}
+
+// TestGameLoop_Recorder checks if the events of the update systems are recorded with their tick
+func TestGameLoop_Recorder(t *testing.T) {
+	scene := &Scene{Name: "game", RenderSystem: &system.RenderSystem{}}
+	gameLoop := &GameLoop{InputManager: &input.Manager{}, Recorder: &system.EventRecorder{}}
+	gameLoop.AddScene(scene)
+	collisionSystem := &system.CollisionSystem{EntityManager: &entity.Manager{}}
+	scene.AddGameUpdateSystem(collisionSystem)
+	scene.AddGameUpdateSystem(&utils.MockSystem{MockFunc: func() {
+		collisionSystem.NotifyEvent(&system.BorderEvent{Side: "top"})
+	}})
+
+	gameLoop.gameUpdate()
+	gameLoop.gameUpdate()
+
+	records := gameLoop.Recorder.Records()
+	if len(records) != 2 || records[0].Tick != 1 || records[1].Tick != 2 || records[1].Name != "border event" {
+		t.Errorf("records == %+v; want a border event in each tick", records)
+	}
+
+	// the systems of the new scene get the recorder when the scene changes
+	menu := &Scene{Name: "menu", RenderSystem: &system.RenderSystem{}}
+	gameLoop.AddScene(menu)
+	menuSystem := &system.CollisionSystem{EntityManager: &entity.Manager{}}
+	menu.AddGameUpdateSystem(menuSystem)
+	menu.AddGameUpdateSystem(&utils.MockSystem{MockFunc: func() {
+		menuSystem.NotifyEvent(&system.BorderEvent{Side: "left"})
+	}})
+	gameLoop.ChangeScene("menu")
+	gameLoop.gameUpdate()
+
+	if records = gameLoop.Recorder.Records(); len(records) != 3 || records[2].Tick != 3 {
+		t.Errorf("records == %+v; want the event of the new scene", records)
+	}
+}
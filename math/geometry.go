@@ -0,0 +1,266 @@
+package math
+
+import (
+	gomath "math"
+)
+
+// Dot returns the dot product of two vectors
+func Dot(a, b FPoint) float32 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+// Length returns the length of the vector
+func Length(a FPoint) float32 {
+	return float32(gomath.Sqrt(float64(a.X*a.X + a.Y*a.Y)))
+}
+
+// RotateFPoint rotates the point around the origin. The angle is in degrees, clockwise on the screen (like the render)
+func RotateFPoint(p FPoint, angle float64) FPoint {
+	if angle == 0 {
+		return p
+	}
+	sin, cos := gomath.Sincos(angle * gomath.Pi / 180)
+	return FPoint{
+		X: float32(float64(p.X)*cos - float64(p.Y)*sin),
+		Y: float32(float64(p.X)*sin + float64(p.Y)*cos),
+	}
+}
+
+// PolygonsOverlap checks if two convex polygons overlap using the separating axis theorem.
+// Polygons only touching each other don't overlap
+func PolygonsOverlap(a, b []FPoint) bool {
+	return !hasSeparatingAxis(a, b) && !hasSeparatingAxis(b, a)
+}
+
+// hasSeparatingAxis checks if one of the edge normals of the polygon a separates the polygons
+func hasSeparatingAxis(a, b []FPoint) bool {
+	for i := range a {
+		p1, p2 := a[i], a[(i+1)%len(a)]
+		axis := FPoint{X: p1.Y - p2.Y, Y: p2.X - p1.X}
+		minA, maxA := project(a, axis)
+		minB, maxB := project(b, axis)
+		if maxA <= minB || maxB <= minA {
+			return true
+		}
+	}
+	return false
+}
+
+// project returns the interval of the projection of the points on the axis
+func project(points []FPoint, axis FPoint) (float32, float32) {
+	min := Dot(points[0], axis)
+	max := min
+	for _, p := range points[1:] {
+		d := Dot(p, axis)
+		if d < min {
+			min = d
+		} else if d > max {
+			max = d
+		}
+	}
+	return min, max
+}
+
+// PointInPolygon checks if the point is inside the convex polygon. The vertices can be in any order (winding)
+func PointInPolygon(p FPoint, polygon []FPoint) bool {
+	var sign float32
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		cross := (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+		if cross == 0 {
+			continue
+		}
+		if sign == 0 {
+			sign = cross
+		} else if sign*cross < 0 {
+			return false
+		}
+	}
+	return sign != 0
+}
+
+// PointSegmentDistance returns the distance between the point p and the segment (a, b)
+func PointSegmentDistance(p, a, b FPoint) float32 {
+	c := ClosestPointOnSegment(p, a, b)
+	return Length(FPoint{X: p.X - c.X, Y: p.Y - c.Y})
+}
+
+// ClosestPointOnSegment returns the point of the segment (a, b) closest to the point p
+func ClosestPointOnSegment(p, a, b FPoint) FPoint {
+	ab := FPoint{X: b.X - a.X, Y: b.Y - a.Y}
+	ap := FPoint{X: p.X - a.X, Y: p.Y - a.Y}
+	t := float32(0)
+	if l := Dot(ab, ab); l > 0 {
+		t = Dot(ap, ab) / l
+		if t < 0 {
+			t = 0
+		} else if t > 1 {
+			t = 1
+		}
+	}
+	return FPoint{X: a.X + ab.X*t, Y: a.Y + ab.Y*t}
+}
+
+// SegmentDistance returns the distance between the segments (a1, a2) and (b1, b2). It's 0 if they intersect
+func SegmentDistance(a1, a2, b1, b2 FPoint) float32 {
+	if segmentsIntersect(a1, a2, b1, b2) {
+		return 0
+	}
+	d := PointSegmentDistance(a1, b1, b2)
+	for _, other := range []float32{
+		PointSegmentDistance(a2, b1, b2),
+		PointSegmentDistance(b1, a1, a2),
+		PointSegmentDistance(b2, a1, a2),
+	} {
+		if other < d {
+			d = other
+		}
+	}
+	return d
+}
+
+// segmentsIntersect checks if the segments cross each other. Collinear segments are handled by the distances
+func segmentsIntersect(a1, a2, b1, b2 FPoint) bool {
+	orientation := func(p, q, r FPoint) float32 {
+		return (q.X-p.X)*(r.Y-p.Y) - (q.Y-p.Y)*(r.X-p.X)
+	}
+	d1 := orientation(b1, b2, a1)
+	d2 := orientation(b1, b2, a2)
+	d3 := orientation(a1, a2, b1)
+	d4 := orientation(a1, a2, b2)
+	return d1*d2 < 0 && d3*d4 < 0
+}
+
+// ConvexDistance returns the distance between two convex shapes. Each shape is a point (one vertex), a segment
+// (two vertices) or a convex polygon. It's 0 if they overlap
+func ConvexDistance(a, b []FPoint) float32 {
+	if len(b) > 2 && PointInPolygon(a[0], b) || len(a) > 2 && PointInPolygon(b[0], a) {
+		return 0
+	}
+	d := float32(gomath.MaxFloat32)
+	for i := range a {
+		a1, a2 := a[i], a[(i+1)%len(a)]
+		for j := range b {
+			b1, b2 := b[j], b[(j+1)%len(b)]
+			if dist := SegmentDistance(a1, a2, b1, b2); dist < d {
+				d = dist
+			}
+		}
+	}
+	return d
+}
+
+// ClosestPoints returns the closest points between two convex shapes that don't overlap, one on each shape.
+// The shapes are points, segments or convex polygons, like in ConvexDistance
+func ClosestPoints(a, b []FPoint) (FPoint, FPoint) {
+	var closestA, closestB FPoint
+	d := float32(gomath.MaxFloat32)
+	check := func(pa, pb FPoint) {
+		if dist := Length(FPoint{X: pb.X - pa.X, Y: pb.Y - pa.Y}); dist < d {
+			d, closestA, closestB = dist, pa, pb
+		}
+	}
+	for i := range a {
+		a1, a2 := a[i], a[(i+1)%len(a)]
+		for j := range b {
+			b1, b2 := b[j], b[(j+1)%len(b)]
+			check(a1, ClosestPointOnSegment(a1, b1, b2))
+			check(a2, ClosestPointOnSegment(a2, b1, b2))
+			check(ClosestPointOnSegment(b1, a1, a2), b1)
+			check(ClosestPointOnSegment(b2, a1, a2), b2)
+		}
+	}
+	return closestA, closestB
+}
+
+// MinimumTranslation returns the axis of least penetration between two overlapping convex shapes and how much
+// they overlap along it. The axis is normalized and points from a to b. The shapes are points, segments or
+// convex polygons, like in ConvexDistance. If there is no edge to separate them (two points), the axis is the
+// direction between them and the overlap is 0
+func MinimumTranslation(a, b []FPoint) (FPoint, float32) {
+	var axis FPoint
+	depth := float32(gomath.MaxFloat32)
+	for _, shape := range [][]FPoint{a, b} {
+		if len(shape) < 2 {
+			continue
+		}
+		for i := range shape {
+			p1, p2 := shape[i], shape[(i+1)%len(shape)]
+			normal := FPoint{X: p1.Y - p2.Y, Y: p2.X - p1.X}
+			l := Length(normal)
+			if l == 0 {
+				continue
+			}
+			normal = FPoint{X: normal.X / l, Y: normal.Y / l}
+			minA, maxA := project(a, normal)
+			minB, maxB := project(b, normal)
+			// b is pushed in the direction it overlaps less
+			if overlap := maxA - minB; overlap < depth {
+				axis, depth = normal, overlap
+			}
+			if overlap := maxB - minA; overlap < depth {
+				axis, depth = FPoint{X: -normal.X, Y: -normal.Y}, overlap
+			}
+		}
+	}
+	if axis != (FPoint{}) {
+		return axis, depth
+	}
+	centerA, centerB := Centroid(a), Centroid(b)
+	direction := FPoint{X: centerB.X - centerA.X, Y: centerB.Y - centerA.Y}
+	if l := Length(direction); l > 0 {
+		return FPoint{X: direction.X / l, Y: direction.Y / l}, 0
+	}
+	return FPoint{X: 0, Y: 1}, 0
+}
+
+// Centroid returns the average of the points
+func Centroid(points []FPoint) FPoint {
+	var c FPoint
+	for _, p := range points {
+		c.X += p.X
+		c.Y += p.Y
+	}
+	n := float32(len(points))
+	return FPoint{X: c.X / n, Y: c.Y / n}
+}
+
+// RayCircle returns the distance from the origin to the point where the ray hits the circle. The direction must be
+// normalized. Rays starting inside the circle hit it at the origin
+func RayCircle(origin, direction, center FPoint, radius float32) (float32, bool) {
+	m := FPoint{X: origin.X - center.X, Y: origin.Y - center.Y}
+	c := Dot(m, m) - radius*radius
+	if c <= 0 {
+		return 0, true
+	}
+	b := Dot(m, direction)
+	if b > 0 {
+		// it's outside and pointing away from the circle
+		return 0, false
+	}
+	discriminant := b*b - c
+	if discriminant < 0 {
+		return 0, false
+	}
+	return -b - float32(gomath.Sqrt(float64(discriminant))), true
+}
+
+// RaySegment returns the distance from the origin to the point where the ray hits the segment (a, b).
+// The direction must be normalized. Rays parallel to the segment don't hit it
+func RaySegment(origin, direction, a, b FPoint) (float32, bool) {
+	cross := func(p, q FPoint) float32 {
+		return p.X*q.Y - p.Y*q.X
+	}
+	edge := FPoint{X: b.X - a.X, Y: b.Y - a.Y}
+	denominator := cross(direction, edge)
+	if denominator == 0 {
+		return 0, false
+	}
+	ao := FPoint{X: a.X - origin.X, Y: a.Y - origin.Y}
+	t := cross(ao, edge) / denominator
+	u := cross(ao, direction) / denominator
+	if t < 0 || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
@@ -0,0 +1,150 @@
+package math
+
+import (
+	"testing"
+)
+
+func TestRotateFPoint(t *testing.T) {
+	cases := []struct {
+		in    FPoint
+		angle float64
+		want  FPoint
+	}{
+		{FPoint{X: 1, Y: 0}, 0, FPoint{X: 1, Y: 0}},
+		{FPoint{X: 1, Y: 0}, 90, FPoint{X: 0, Y: 1}},
+		{FPoint{X: 0, Y: 2}, 90, FPoint{X: -2, Y: 0}},
+		{FPoint{X: 1, Y: 1}, 180, FPoint{X: -1, Y: -1}},
+	}
+	for _, c := range cases {
+		got := RotateFPoint(c.in, c.angle)
+		if diff := Length(FPoint{X: got.X - c.want.X, Y: got.Y - c.want.Y}); diff > 0.0001 {
+			t.Errorf("RotateFPoint(%v, %f) == %v; want %v", c.in, c.angle, got, c.want)
+		}
+	}
+}
+
+func TestPolygonsOverlap(t *testing.T) {
+	square := []FPoint{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	cases := []struct {
+		name    string
+		polygon []FPoint
+		want    bool
+	}{
+		{"overlapping", []FPoint{{X: 5, Y: 5}, {X: 15, Y: 5}, {X: 15, Y: 15}}, true},
+		{"touching", []FPoint{{X: 10, Y: 0}, {X: 20, Y: 0}, {X: 20, Y: 10}}, false},
+		{"inside", []FPoint{{X: 2, Y: 2}, {X: 4, Y: 2}, {X: 3, Y: 4}}, true},
+		// the bounding boxes overlap, but not the triangle
+		{"diagonal", []FPoint{{X: 22, Y: 0}, {X: 22, Y: 22}, {X: 0, Y: 22}}, false},
+		{"counter clockwise", []FPoint{{X: 5, Y: 5}, {X: 5, Y: 15}, {X: 15, Y: 15}}, true},
+	}
+	for _, c := range cases {
+		if got := PolygonsOverlap(square, c.polygon); got != c.want {
+			t.Errorf("%s: PolygonsOverlap() == %v; want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestConvexDistance(t *testing.T) {
+	square := []FPoint{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	cases := []struct {
+		name  string
+		shape []FPoint
+		want  float32
+	}{
+		{"point inside", []FPoint{{X: 5, Y: 5}}, 0},
+		{"point", []FPoint{{X: 13, Y: 14}}, 5},
+		{"segment crossing", []FPoint{{X: -5, Y: 5}, {X: 15, Y: 5}}, 0},
+		{"segment", []FPoint{{X: 12, Y: -5}, {X: 12, Y: 15}}, 2},
+		{"polygon", []FPoint{{X: 0, Y: 13}, {X: 10, Y: 13}, {X: 5, Y: 20}}, 3},
+	}
+	for _, c := range cases {
+		if got := ConvexDistance(square, c.shape); got-c.want > 0.0001 || c.want-got > 0.0001 {
+			t.Errorf("%s: ConvexDistance() == %f; want %f", c.name, got, c.want)
+		}
+	}
+}
+
+func TestClosestPoints(t *testing.T) {
+	square := []FPoint{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	cases := []struct {
+		name  string
+		shape []FPoint
+		wantA FPoint
+		wantB FPoint
+	}{
+		{"point", []FPoint{{X: 15, Y: 5}}, FPoint{X: 10, Y: 5}, FPoint{X: 15, Y: 5}},
+		{"corner", []FPoint{{X: 13, Y: 14}}, FPoint{X: 10, Y: 10}, FPoint{X: 13, Y: 14}},
+		{"segment", []FPoint{{X: 12, Y: 5}, {X: 20, Y: -5}}, FPoint{X: 10, Y: 5}, FPoint{X: 12, Y: 5}},
+	}
+	for _, c := range cases {
+		a, b := ClosestPoints(square, c.shape)
+		if a != c.wantA || b != c.wantB {
+			t.Errorf("%s: ClosestPoints() == (%v, %v); want (%v, %v)", c.name, a, b, c.wantA, c.wantB)
+		}
+	}
+}
+
+func TestMinimumTranslation(t *testing.T) {
+	square := []FPoint{{X: 0, Y: 0}, {X: 10, Y: 0}, {X: 10, Y: 10}, {X: 0, Y: 10}}
+	cases := []struct {
+		name      string
+		shape     []FPoint
+		wantAxis  FPoint
+		wantDepth float32
+	}{
+		{"right", []FPoint{{X: 8, Y: 2}, {X: 18, Y: 2}, {X: 18, Y: 8}, {X: 8, Y: 8}}, FPoint{X: 1, Y: 0}, 2},
+		{"above", []FPoint{{X: 2, Y: -7}, {X: 8, Y: -7}, {X: 8, Y: 3}, {X: 2, Y: 3}}, FPoint{X: 0, Y: -1}, 3},
+		{"point", []FPoint{{X: 9, Y: 5}}, FPoint{X: 1, Y: 0}, 1},
+	}
+	for _, c := range cases {
+		axis, depth := MinimumTranslation(square, c.shape)
+		if axis != c.wantAxis || depth != c.wantDepth {
+			t.Errorf("%s: MinimumTranslation() == (%v, %f); want (%v, %f)", c.name, axis, depth, c.wantAxis,
+				c.wantDepth)
+		}
+	}
+}
+
+func TestRayCircle(t *testing.T) {
+	center := FPoint{X: 10, Y: 0}
+	cases := []struct {
+		name      string
+		origin    FPoint
+		direction FPoint
+		want      float32
+		wantHit   bool
+	}{
+		{"hit", FPoint{X: 0, Y: 0}, FPoint{X: 1, Y: 0}, 8, true},
+		{"away", FPoint{X: 0, Y: 0}, FPoint{X: -1, Y: 0}, 0, false},
+		{"miss", FPoint{X: 0, Y: 3}, FPoint{X: 1, Y: 0}, 0, false},
+		{"inside", FPoint{X: 11, Y: 0}, FPoint{X: 1, Y: 0}, 0, true},
+	}
+	for _, c := range cases {
+		got, hit := RayCircle(c.origin, c.direction, center, 2)
+		if got != c.want || hit != c.wantHit {
+			t.Errorf("%s: RayCircle() == (%f, %v); want (%f, %v)", c.name, got, hit, c.want, c.wantHit)
+		}
+	}
+}
+
+func TestRaySegment(t *testing.T) {
+	a, b := FPoint{X: 10, Y: -5}, FPoint{X: 10, Y: 5}
+	cases := []struct {
+		name      string
+		origin    FPoint
+		direction FPoint
+		want      float32
+		wantHit   bool
+	}{
+		{"hit", FPoint{X: 0, Y: 0}, FPoint{X: 1, Y: 0}, 10, true},
+		{"behind", FPoint{X: 20, Y: 0}, FPoint{X: 1, Y: 0}, 0, false},
+		{"beside", FPoint{X: 0, Y: 6}, FPoint{X: 1, Y: 0}, 0, false},
+		{"parallel", FPoint{X: 10, Y: -10}, FPoint{X: 0, Y: 1}, 0, false},
+	}
+	for _, c := range cases {
+		got, hit := RaySegment(c.origin, c.direction, a, b)
+		if got != c.want || hit != c.wantHit {
+			t.Errorf("%s: RaySegment() == (%f, %v); want (%f, %v)", c.name, got, hit, c.want, c.wantHit)
+		}
+	}
+}
@@ -3,6 +3,8 @@
 package math
 
 import (
+	gomath "math"
+
 	"github.com/veandco/go-sdl2/sdl"
 )
 
@@ -84,3 +86,85 @@ func Round64(num float64) int64 {
 	}
 	return int64(num + 0.5)
 }
+
+// SweepRect checks if the rectangle a, moving by the vector v, hits the rectangle b.
+// It returns the time of impact (fraction of v between 0 and 1) and the normal of the hit, pointing from a to b.
+// Rectangles that are already overlapping are not considered a hit.
+func SweepRect(a sdl.Rect, v FPoint, b sdl.Rect) (float32, FPoint, bool) {
+	entryX, exitX, ok := sweepAxis(a.X, a.W, v.X, b.X, b.W)
+	if !ok {
+		return 0, FPoint{}, false
+	}
+	entryY, exitY, ok := sweepAxis(a.Y, a.H, v.Y, b.Y, b.H)
+	if !ok {
+		return 0, FPoint{}, false
+	}
+	entry := entryX
+	normal := FPoint{1, 0}
+	if v.X < 0 {
+		normal = FPoint{-1, 0}
+	}
+	if entryY > entryX {
+		entry = entryY
+		normal = FPoint{0, 1}
+		if v.Y < 0 {
+			normal = FPoint{0, -1}
+		}
+	}
+	exit := exitX
+	if exitY < exitX {
+		exit = exitY
+	}
+	if entry > exit || entry < 0 || entry > 1 {
+		return 0, FPoint{}, false
+	}
+	return entry, normal, true
+}
+
+// sweepAxis returns the times that the segment (pos, size) moving by vel enters and leaves the segment (pos2, size2).
+// It returns false if they never overlap
+func sweepAxis(pos, size int32, vel float32, pos2, size2 int32) (float32, float32, bool) {
+	if vel == 0 {
+		if pos < pos2+size2 && pos2 < pos+size {
+			return float32(gomath.Inf(-1)), float32(gomath.Inf(1)), true
+		}
+		return 0, 0, false
+	}
+	var entry, exit float32
+	if vel > 0 {
+		entry = float32(pos2 - (pos + size))
+		exit = float32(pos2 + size2 - pos)
+	} else {
+		entry = float32(pos2 + size2 - pos)
+		exit = float32(pos2 - (pos + size))
+	}
+	return entry / vel, exit / vel, true
+}
+
+// SweepCircle checks if the circle (c, r), moving by the vector v, hits the circle (c2, r2).
+// It returns the time of impact (fraction of v between 0 and 1) and the normal of the hit, pointing from c to c2.
+// Circles that are already overlapping are not considered a hit.
+func SweepCircle(c FPoint, r float32, v FPoint, c2 FPoint, r2 float32) (float32, FPoint, bool) {
+	// solve |c + v*t - c2| = r + r2
+	d := FPoint{c.X - c2.X, c.Y - c2.Y}
+	radius := r + r2
+	a := v.X*v.X + v.Y*v.Y
+	b := 2 * (d.X*v.X + d.Y*v.Y)
+	cc := d.X*d.X + d.Y*d.Y - radius*radius
+	if a == 0 || cc < 0 {
+		return 0, FPoint{}, false
+	}
+	discriminant := b*b - 4*a*cc
+	if discriminant < 0 {
+		return 0, FPoint{}, false
+	}
+	t := (-b - float32(gomath.Sqrt(float64(discriminant)))) / (2 * a)
+	if t < 0 || t > 1 {
+		return 0, FPoint{}, false
+	}
+	normal := FPoint{c2.X - (c.X + v.X*t), c2.Y - (c.Y + v.Y*t)}
+	if length := float32(gomath.Sqrt(float64(normal.X*normal.X + normal.Y*normal.Y))); length > 0 {
+		normal = FPoint{normal.X / length, normal.Y / length}
+	}
+	return t, normal, true
+}
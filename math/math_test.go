@@ -145,6 +145,54 @@ func TestRound64(t *testing.T) {
 	}
 }
 
+func TestSweepRect(t *testing.T) {
+	wall := sdl.Rect{100, 0, 5, 100}
+	cases := []struct {
+		inA        sdl.Rect
+		inV        FPoint
+		wantHit    bool
+		wantTOI    float32
+		wantNormal FPoint
+	}{
+		{sdl.Rect{0, 10, 10, 10}, FPoint{200, 0}, true, 0.45, FPoint{1, 0}},
+		{sdl.Rect{200, 10, 10, 10}, FPoint{-200, 0}, true, 0.475, FPoint{-1, 0}},
+		{sdl.Rect{100, -50, 5, 10}, FPoint{0, 100}, true, 0.4, FPoint{0, 1}},
+		{sdl.Rect{0, 10, 10, 10}, FPoint{50, 0}, false, 0, FPoint{}},
+		{sdl.Rect{0, 10, 10, 10}, FPoint{-200, 0}, false, 0, FPoint{}},
+		{sdl.Rect{0, 200, 10, 10}, FPoint{200, 0}, false, 0, FPoint{}},
+		{sdl.Rect{101, 10, 10, 10}, FPoint{200, 0}, false, 0, FPoint{}},
+	}
+	for _, c := range cases {
+		toi, normal, hit := SweepRect(c.inA, c.inV, wall)
+		if hit != c.wantHit || toi != c.wantTOI || normal != c.wantNormal {
+			t.Errorf("SweepRect(%v, %v, %v) == (%f, %v, %t), want (%f, %v, %t)", c.inA, c.inV, wall,
+				toi, normal, hit, c.wantTOI, c.wantNormal, c.wantHit)
+		}
+	}
+}
+
+func TestSweepCircle(t *testing.T) {
+	cases := []struct {
+		inC        FPoint
+		inV        FPoint
+		wantHit    bool
+		wantTOI    float32
+		wantNormal FPoint
+	}{
+		{FPoint{0, 0}, FPoint{100, 0}, true, 0.3, FPoint{1, 0}},
+		{FPoint{0, 0}, FPoint{10, 0}, false, 0, FPoint{}},
+		{FPoint{0, 50}, FPoint{100, 0}, false, 0, FPoint{}},
+		{FPoint{45, 0}, FPoint{100, 0}, false, 0, FPoint{}},
+	}
+	for _, c := range cases {
+		toi, normal, hit := SweepCircle(c.inC, 10, c.inV, FPoint{50, 0}, 10)
+		if hit != c.wantHit || toi != c.wantTOI || normal != c.wantNormal {
+			t.Errorf("SweepCircle(%v, 10, %v, {50 0}, 10) == (%f, %v, %t), want (%f, %v, %t)", c.inC, c.inV,
+				toi, normal, hit, c.wantTOI, c.wantNormal, c.wantHit)
+		}
+	}
+}
+
 func BenchmarkRound(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		Round(10.10)
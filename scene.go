@@ -11,10 +11,18 @@ type SceneManager struct {
 	currentPos int
 	// SceneMap has the position of the scene in the array
 	SceneMap map[string]int
+	// EventBus is shared by all the scenes. It's created when the first scene is added, if it's not set.
+	// The queued events are dropped when the scene changes. The handlers subscribed with Scene.Subscribe are
+	// removed when their scene exits, the ones subscribed directly to the bus are kept
+	EventBus *system.EventBus
 }
 
 // AddScene adds a new scene
 func (s *SceneManager) AddScene(scene *Scene) {
+	if s.EventBus == nil {
+		s.EventBus = &system.EventBus{}
+	}
+	scene.EventBus = s.EventBus
 	s.Scenes = append(s.Scenes, scene)
 	if len(s.Scenes) == 1 {
 		scene.Init()
@@ -42,7 +50,7 @@ func (s *SceneManager) RemoveScene() {
 
 // NextScene goes to the next scene if it exists
 func (s *SceneManager) NextScene() *Scene {
-	(s.Current()).Exit()
+	s.exitCurrent()
 	if s.currentPos < (len(s.Scenes) - 1) {
 		s.currentPos++
 	} else {
@@ -55,13 +63,21 @@ func (s *SceneManager) NextScene() *Scene {
 // ChangeScene changes to a specific scene by its name
 func (s *SceneManager) ChangeScene(sceneName string) *Scene {
 	if pos, ok := s.SceneMap[sceneName]; ok {
-		(s.Current()).Exit()
+		s.exitCurrent()
 		s.currentPos = pos
 	}
 	s.Current().Init()
 	return s.Current()
 }
 
+// exitCurrent exits the current scene and drops the events it queued in the event bus
+func (s *SceneManager) exitCurrent() {
+	s.Current().Exit()
+	if s.EventBus != nil {
+		s.EventBus.ClearQueue()
+	}
+}
+
 // Scene is responsible to hold the systems in a scene
 type Scene struct {
 	Name          string
@@ -69,6 +85,9 @@ type Scene struct {
 	RenderSystem  *system.RenderSystem // responsible to render the game
 	InitFunc      func()
 	ExitFunc      func()
+	// EventBus is the event bus of the game, set when the scene is added to the scene manager
+	EventBus      *system.EventBus
+	subscriptions []system.Subscription
 	SceneOptions
 }
 
@@ -93,11 +112,22 @@ func (s *Scene) Init() {
 	}
 }
 
-// Exit executes a function, if setted, when scene is excited
+// Exit executes a function, if setted, when scene is excited. Then the handlers subscribed by the scene are removed
 func (s *Scene) Exit() {
 	if s.ExitFunc != nil {
 		s.ExitFunc()
 	}
+	for _, subscription := range s.subscriptions {
+		s.EventBus.Unsubscribe(subscription)
+	}
+	s.subscriptions = nil
+}
+
+// Subscribe adds a handler to the event bus until the scene exits. See EventBus.Subscribe
+func (s *Scene) Subscribe(event system.Event, handler system.EventHandler) system.Subscription {
+	subscription := s.EventBus.Subscribe(event, handler)
+	s.subscriptions = append(s.subscriptions, subscription)
+	return subscription
 }
 
 // AddGameUpdateSystem adds the systems which will run in the game loop
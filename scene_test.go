@@ -168,3 +168,25 @@ func TestSceneManager(t *testing.T) {
 		}
 	})
 }
+
+func TestSceneManager_EventBus(t *testing.T) {
+	scene1 := &Scene{Name: "scene1", RenderSystem: &system.RenderSystem{}}
+	scene2 := &Scene{Name: "scene2", RenderSystem: &system.RenderSystem{}}
+	sm := SceneManager{}
+	sm.AddScene(scene1)
+	sm.AddScene(scene2)
+	if sm.EventBus == nil || scene1.EventBus != sm.EventBus || scene2.EventBus != sm.EventBus {
+		t.Error("The scenes are not sharing the event bus of the scene manager")
+	}
+
+	// the handlers of the first scene and the events it queued don't reach the second one
+	calls := 0
+	scene1.Subscribe(&system.BorderEvent{}, func(e system.Event) { calls++ })
+	sm.EventBus.Queue(&system.BorderEvent{})
+	sm.ChangeScene("scene2")
+	sm.EventBus.Flush()
+	sm.EventBus.Publish(&system.BorderEvent{})
+	if calls != 0 {
+		t.Errorf("The handler of the previous scene was called %d times", calls)
+	}
+}
@@ -0,0 +1,306 @@
+package system
+
+import (
+	"sort"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	// defaultCellSize is the cell size of the spatial hash if none is set
+	defaultCellSize = 64
+	// defaultQuadtreeObjects is the number of boxes a quadtree node holds before it is split if none is set
+	defaultQuadtreeObjects = 8
+	// defaultQuadtreeDepth is the maximum depth of the quadtree if none is set
+	defaultQuadtreeDepth = 8
+)
+
+// Broadphase finds the pairs of colliders whose bounding boxes overlap, so the collision system
+// only checks the collision areas of colliders that are close to each other.
+// The boxes are inserted again every tick
+type Broadphase interface {
+	// Clear removes all the boxes
+	Clear()
+	// Insert adds the bounding box of the collider with the given index
+	Insert(index int, box sdl.Rect)
+	// Pairs returns the pairs of colliders whose boxes overlap. The collision system sorts them,
+	// so they can be in any order
+	Pairs() []Pair
+}
+
+// Pair is a pair of colliders (their indexes) found by the broadphase
+type Pair struct {
+	A int
+	B int
+}
+
+// indexedBox is a bounding box and the index of its collider
+type indexedBox struct {
+	index int
+	box   sdl.Rect
+}
+
+// pairOf creates the pair of both indexes, with the lowest one first
+func pairOf(a, b int) Pair {
+	if a > b {
+		a, b = b, a
+	}
+	return Pair{A: a, B: b}
+}
+
+// overlaps checks if the boxes overlap. Boxes only touching each other don't overlap
+func overlaps(a, b sdl.Rect) bool {
+	return a.X < b.X+b.W && b.X < a.X+a.W && a.Y < b.Y+b.H && b.Y < a.Y+a.H
+}
+
+// sortPairs sorts the pairs and removes the duplicated ones
+func sortPairs(pairs []Pair) []Pair {
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	unique := pairs[:0]
+	for i, pair := range pairs {
+		if i == 0 || pair != pairs[i-1] {
+			unique = append(unique, pair)
+		}
+	}
+	return unique
+}
+
+// orderedPairs returns a sorted copy of the pairs found by a broadphase, with the lowest index first
+func orderedPairs(pairs []Pair) []Pair {
+	ordered := make([]Pair, len(pairs))
+	for i, pair := range pairs {
+		ordered[i] = pairOf(pair.A, pair.B)
+	}
+	return sortPairs(ordered)
+}
+
+// BruteForce checks every box against all the others. It is only recommended for a few colliders
+type BruteForce struct {
+	boxes []indexedBox
+}
+
+// Clear removes all the boxes
+func (b *BruteForce) Clear() {
+	b.boxes = b.boxes[:0]
+}
+
+// Insert adds the bounding box of the collider with the given index
+func (b *BruteForce) Insert(index int, box sdl.Rect) {
+	b.boxes = append(b.boxes, indexedBox{index, box})
+}
+
+// Pairs returns the pairs of colliders whose boxes overlap
+func (b *BruteForce) Pairs() []Pair {
+	var pairs []Pair
+	for i, box1 := range b.boxes {
+		for _, box2 := range b.boxes[i+1:] {
+			if overlaps(box1.box, box2.box) {
+				pairs = append(pairs, pairOf(box1.index, box2.index))
+			}
+		}
+	}
+	return sortPairs(pairs)
+}
+
+// SpatialHash divides the world in a uniform grid of cells. Only the boxes that share a cell are checked.
+// It works best when the colliders have similar sizes and the cell is a bit bigger than them
+type SpatialHash struct {
+	// CellSize is the width and height of the cells. Default is 64
+	CellSize int32
+	boxes    []indexedBox
+	cells    map[cell][]int // position of the boxes in each cell
+}
+
+// cell is the coordinate of a cell in the spatial hash
+type cell struct {
+	X int32
+	Y int32
+}
+
+// Clear removes all the boxes
+func (s *SpatialHash) Clear() {
+	s.boxes = s.boxes[:0]
+	// the cells are kept so we don't allocate them again every tick
+	for key, boxes := range s.cells {
+		if len(boxes) == 0 {
+			delete(s.cells, key)
+		} else {
+			s.cells[key] = boxes[:0]
+		}
+	}
+}
+
+// Insert adds the bounding box of the collider with the given index to all the cells it touches
+func (s *SpatialHash) Insert(index int, box sdl.Rect) {
+	if s.CellSize <= 0 {
+		s.CellSize = defaultCellSize
+	}
+	if s.cells == nil {
+		s.cells = make(map[cell][]int)
+	}
+	s.boxes = append(s.boxes, indexedBox{index, box})
+	i := len(s.boxes) - 1
+	x0, y0 := s.cellOf(box.X), s.cellOf(box.Y)
+	x1, y1 := s.cellOf(box.X+box.W-1), s.cellOf(box.Y+box.H-1)
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			key := cell{x, y}
+			s.cells[key] = append(s.cells[key], i)
+		}
+	}
+}
+
+// Pairs returns the pairs of colliders whose boxes overlap
+func (s *SpatialHash) Pairs() []Pair {
+	var pairs []Pair
+	for _, boxes := range s.cells {
+		for i, box1 := range boxes {
+			for _, box2 := range boxes[i+1:] {
+				if overlaps(s.boxes[box1].box, s.boxes[box2].box) {
+					pairs = append(pairs, pairOf(s.boxes[box1].index, s.boxes[box2].index))
+				}
+			}
+		}
+	}
+	// boxes sharing more than one cell are found more than once
+	return sortPairs(pairs)
+}
+
+// cellOf returns the cell coordinate of the given world coordinate
+func (s *SpatialHash) cellOf(v int32) int32 {
+	c := v / s.CellSize
+	if v < 0 && v%s.CellSize != 0 {
+		c--
+	}
+	return c
+}
+
+// Quadtree recursively divides the space occupied by the boxes in four quadrants. It is rebuilt when
+// the pairs are requested, so it always fits the colliders. It works well with colliders of different sizes
+type Quadtree struct {
+	// MaxObjects is the number of boxes a node holds before it is split. Default is 8
+	MaxObjects int
+	// MaxDepth is the maximum depth of the tree. Default is 8
+	MaxDepth int
+	boxes    []indexedBox
+}
+
+// quadNode is a node of the quadtree. The boxes that don't fit entirely in a quadrant stay in the node
+type quadNode struct {
+	bounds   sdl.Rect
+	depth    int
+	boxes    []indexedBox
+	children []*quadNode
+}
+
+// Clear removes all the boxes
+func (q *Quadtree) Clear() {
+	q.boxes = q.boxes[:0]
+}
+
+// Insert adds the bounding box of the collider with the given index
+func (q *Quadtree) Insert(index int, box sdl.Rect) {
+	q.boxes = append(q.boxes, indexedBox{index, box})
+}
+
+// Pairs builds the tree and returns the pairs of colliders whose boxes overlap
+func (q *Quadtree) Pairs() []Pair {
+	if len(q.boxes) == 0 {
+		return nil
+	}
+	if q.MaxObjects <= 0 {
+		q.MaxObjects = defaultQuadtreeObjects
+	}
+	if q.MaxDepth <= 0 {
+		q.MaxDepth = defaultQuadtreeDepth
+	}
+	bounds := q.boxes[0].box
+	for _, b := range q.boxes[1:] {
+		bounds = bounds.Union(&b.box)
+	}
+	root := &quadNode{bounds: bounds}
+	for _, b := range q.boxes {
+		q.insert(root, b)
+	}
+	var pairs []Pair
+	root.pairs(&pairs)
+	return sortPairs(pairs)
+}
+
+// insert adds the box to the node or to the quadrant that contains it, splitting the node if it's full
+func (q *Quadtree) insert(node *quadNode, b indexedBox) {
+	if node.children != nil {
+		if child := node.quadrant(b.box); child != nil {
+			q.insert(child, b)
+			return
+		}
+		node.boxes = append(node.boxes, b)
+		return
+	}
+	node.boxes = append(node.boxes, b)
+	if len(node.boxes) <= q.MaxObjects || node.depth >= q.MaxDepth || node.bounds.W < 2 || node.bounds.H < 2 {
+		return
+	}
+	// split the node and move the boxes that fit in a quadrant
+	w, h := node.bounds.W/2, node.bounds.H/2
+	x, y := node.bounds.X, node.bounds.Y
+	node.children = []*quadNode{
+		{bounds: sdl.Rect{X: x, Y: y, W: w, H: h}, depth: node.depth + 1},
+		{bounds: sdl.Rect{X: x + w, Y: y, W: node.bounds.W - w, H: h}, depth: node.depth + 1},
+		{bounds: sdl.Rect{X: x, Y: y + h, W: w, H: node.bounds.H - h}, depth: node.depth + 1},
+		{bounds: sdl.Rect{X: x + w, Y: y + h, W: node.bounds.W - w, H: node.bounds.H - h}, depth: node.depth + 1},
+	}
+	boxes := node.boxes
+	node.boxes = nil
+	for _, b := range boxes {
+		q.insert(node, b)
+	}
+}
+
+// quadrant returns the child that contains the whole box, or nil if the box doesn't fit in any of them
+func (n *quadNode) quadrant(box sdl.Rect) *quadNode {
+	for _, child := range n.children {
+		b := child.bounds
+		if box.X >= b.X && box.Y >= b.Y && box.X+box.W <= b.X+b.W && box.Y+box.H <= b.Y+b.H {
+			return child
+		}
+	}
+	return nil
+}
+
+// pairs appends the overlapping boxes of this node, and of this node with its descendants
+func (n *quadNode) pairs(pairs *[]Pair) {
+	for i, box1 := range n.boxes {
+		for _, box2 := range n.boxes[i+1:] {
+			if overlaps(box1.box, box2.box) {
+				*pairs = append(*pairs, pairOf(box1.index, box2.index))
+			}
+		}
+		for _, child := range n.children {
+			child.pairsWith(box1, pairs)
+		}
+	}
+	for _, child := range n.children {
+		child.pairs(pairs)
+	}
+}
+
+// pairsWith appends the boxes of this node and its descendants that overlap the given box
+func (n *quadNode) pairsWith(b indexedBox, pairs *[]Pair) {
+	if !overlaps(n.bounds, b.box) {
+		return
+	}
+	for _, box := range n.boxes {
+		if overlaps(box.box, b.box) {
+			*pairs = append(*pairs, pairOf(box.index, b.index))
+		}
+	}
+	for _, child := range n.children {
+		child.pairsWith(b, pairs)
+	}
+}
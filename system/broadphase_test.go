@@ -0,0 +1,157 @@
+package system
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// randomBoxes creates boxes of different sizes spread in the world
+func randomBoxes(n int, world int32) []sdl.Rect {
+	r := rand.New(rand.NewSource(1))
+	boxes := make([]sdl.Rect, n)
+	for i := range boxes {
+		boxes[i] = sdl.Rect{
+			X: r.Int31n(world) - world/2,
+			Y: r.Int31n(world) - world/2,
+			W: r.Int31n(60) + 1,
+			H: r.Int31n(60) + 1,
+		}
+	}
+	return boxes
+}
+
+func TestBroadphase_Pairs(t *testing.T) {
+	boxes := randomBoxes(500, 1000)
+	want := &BruteForce{}
+	for i, box := range boxes {
+		want.Insert(i, box)
+	}
+	wantPairs := want.Pairs()
+	if len(wantPairs) == 0 {
+		t.Fatal("no overlapping boxes")
+	}
+
+	cases := []struct {
+		name       string
+		broadphase Broadphase
+	}{
+		{"spatial hash", &SpatialHash{}},
+		{"small cells", &SpatialHash{CellSize: 7}},
+		{"quadtree", &Quadtree{}},
+		{"shallow quadtree", &Quadtree{MaxObjects: 2, MaxDepth: 2}},
+	}
+	for _, c := range cases {
+		// the second time checks that everything is cleared
+		for i := 0; i < 2; i++ {
+			c.broadphase.Clear()
+			for i, box := range boxes {
+				c.broadphase.Insert(i, box)
+			}
+			if pairs := c.broadphase.Pairs(); !reflect.DeepEqual(pairs, wantPairs) {
+				t.Errorf("%s: %d pairs; want %d", c.name, len(pairs), len(wantPairs))
+			}
+		}
+	}
+}
+
+// reversedPairs is a broadphase that returns the pairs in the reverse order, with the highest index first
+type reversedPairs struct {
+	BruteForce
+}
+
+func (r *reversedPairs) Pairs() []Pair {
+	pairs := r.BruteForce.Pairs()
+	reversed := make([]Pair, len(pairs))
+	for i, pair := range pairs {
+		reversed[len(pairs)-1-i] = Pair{A: pair.B, B: pair.A}
+	}
+	return reversed
+}
+
+func TestCollisionSystem_Broadphase(t *testing.T) {
+	cases := []struct {
+		name       string
+		broadphase Broadphase
+	}{
+		{"default", nil},
+		{"quadtree", &Quadtree{}},
+		{"brute force", &BruteForce{}},
+		{"unsorted pairs", &reversedPairs{}},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		areas := []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}
+		a := createCollider(em, 100, 100, &entity.CollisionComponent{CollisionAreas: areas})
+		b := createCollider(em, 105, 105, &entity.CollisionComponent{CollisionAreas: areas})
+		createCollider(em, 90, 100, &entity.CollisionComponent{CollisionAreas: areas}) // touching a, but not colliding
+		d := createCollider(em, 300, 300, &entity.CollisionComponent{CollisionAreas: areas})
+		e := createCollider(em, 300, 309, &entity.CollisionComponent{CollisionAreas: areas})
+		createCollider(em, 300, 300, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{}}}) // no collision areas
+		// moved through the ball in this tick
+		f := createCollider(em, 400, 100, &entity.CollisionComponent{CollisionAreas: areas, Continuous: true})
+		f.AddComponent(&entity.PhysicsComponent{PrevPos: &math.FPoint{X: 200, Y: 100},
+			FuturePos: &math.FPoint{X: 400, Y: 100}})
+		g := createCollider(em, 300, 100, &entity.CollisionComponent{CollisionAreas: areas})
+
+		var events [][2]*entity.Entity
+		cs := &CollisionSystem{EntityManager: em, Broadphase: c.broadphase}
+		cs.AddHandler("collision event", func(e Event) {
+			event := e.(*CollisionEvent)
+			events = append(events, [2]*entity.Entity{event.Ent, event.With})
+		})
+		cs.Update()
+
+		want := [][2]*entity.Entity{{a, b}, {b, a}, {d, e}, {e, d}, {f, g}, {g, f}}
+		if !reflect.DeepEqual(events, want) {
+			t.Errorf("%s: events == %v; want %v", c.name, events, want)
+		}
+	}
+}
+
+// benchmarkCollisionSystem updates the collision system with moving colliders spread in the world.
+// At 50 ticks per second each update should take less than 20ms
+func benchmarkCollisionSystem(b *testing.B, broadphase Broadphase, n int) {
+	em := &entity.Manager{}
+	for _, box := range randomBoxes(n, 4000) {
+		area := sdl.Rect{X: 0, Y: 0, W: box.W%30 + 2, H: box.H%30 + 2}
+		obj := createCollider(em, box.X, box.Y, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{area}})
+		obj.AddComponent(&entity.PhysicsComponent{Vel: &math.FPoint{X: float32(box.W), Y: float32(box.H)}})
+	}
+	ps := &PhysicsSystem{EntityManager: em}
+	cs := &CollisionSystem{EntityManager: em, Broadphase: broadphase}
+	cs.AddHandler("collision event", func(e Event) {})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps.Update()
+		cs.Update()
+	}
+}
+
+func BenchmarkCollisionSystem_BruteForce1000(b *testing.B) {
+	benchmarkCollisionSystem(b, &BruteForce{}, 1000)
+}
+
+func BenchmarkCollisionSystem_BruteForce5000(b *testing.B) {
+	benchmarkCollisionSystem(b, &BruteForce{}, 5000)
+}
+
+func BenchmarkCollisionSystem_SpatialHash1000(b *testing.B) {
+	benchmarkCollisionSystem(b, &SpatialHash{}, 1000)
+}
+
+func BenchmarkCollisionSystem_SpatialHash5000(b *testing.B) {
+	benchmarkCollisionSystem(b, &SpatialHash{}, 5000)
+}
+
+func BenchmarkCollisionSystem_Quadtree1000(b *testing.B) {
+	benchmarkCollisionSystem(b, &Quadtree{}, 1000)
+}
+
+func BenchmarkCollisionSystem_Quadtree5000(b *testing.B) {
+	benchmarkCollisionSystem(b, &Quadtree{}, 5000)
+}
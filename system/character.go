@@ -0,0 +1,234 @@
+package system
+
+import (
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// CharacterControllerSystem moves the characters against the solid colliders, sliding along them.
+// The physics system updates the velocity of the characters (e.g. gravity), but their position is updated here
+type CharacterControllerSystem struct {
+	EntityManager *entity.Manager
+	Name          string
+	Subject
+}
+
+// solid is a collider that blocks the characters
+type solid struct {
+	obj       *entity.Entity
+	z         float32
+	collision *entity.CollisionComponent
+	rects     []sdl.Rect // collision areas in the world
+	oneWay    bool
+	physics   *entity.PhysicsComponent
+}
+
+// Init initializes this system. So far it does nothing.
+func (c *CharacterControllerSystem) Init() {}
+
+// Update jumps and moves the characters according to their velocity
+func (c *CharacterControllerSystem) Update() {
+	dt := DeltaTime()
+	solids := c.solids()
+
+	requiredComponents := []entity.Component{&entity.PositionComponent{}, &entity.PhysicsComponent{},
+		&entity.CollisionComponent{}, &entity.CharacterControllerComponent{}}
+	it := c.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		physics := obj.GetComponent(&entity.PhysicsComponent{}).(*entity.PhysicsComponent)
+		collision := obj.GetComponent(&entity.CollisionComponent{}).(*entity.CollisionComponent)
+		controller := obj.GetComponent(&entity.CharacterControllerComponent{}).(*entity.CharacterControllerComponent)
+		if len(collision.CollisionAreas) == 0 {
+			continue
+		}
+		if physics.Vel == nil {
+			physics.Vel = &math.FPoint{}
+		}
+		jump(controller, physics, dt)
+		move(position, physics, collision, controller, solids, dt)
+	}
+}
+
+// solids gets the colliders that block the characters
+func (c *CharacterControllerSystem) solids() []solid {
+	var solids []solid
+	requiredComponents := []entity.Component{&entity.PositionComponent{}, &entity.CollisionComponent{}}
+	it := c.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		if obj.GetComponent(&entity.CharacterControllerComponent{}) != nil {
+			continue
+		}
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		collision := obj.GetComponent(&entity.CollisionComponent{}).(*entity.CollisionComponent)
+		if collision.Sensor {
+			continue
+		}
+		s := solid{obj: obj, z: position.Z, collision: collision, oneWay: collision.OneWay}
+		// moving platforms use their simulated position, since the rendered position is interpolated
+		x, y := position.Pos.X, position.Pos.Y
+		if component := obj.GetComponent(&entity.PhysicsComponent{}); component != nil {
+			s.physics = component.(*entity.PhysicsComponent)
+			if s.physics.FuturePos != nil {
+				x, y = math.Round(s.physics.FuturePos.X), math.Round(s.physics.FuturePos.Y)
+			}
+		}
+		for _, area := range collision.CollisionAreas {
+			s.rects = append(s.rects, sdl.Rect{X: x + area.X, Y: y + area.Y, W: area.W, H: area.H})
+		}
+		solids = append(solids, s)
+	}
+	return solids
+}
+
+// jump makes the character jump if it was requested and it's possible
+func jump(controller *entity.CharacterControllerComponent, physics *entity.PhysicsComponent, dt float32) {
+	if controller.IsGrounded() {
+		controller.CoyoteTimer = controller.CoyoteTime
+	} else {
+		controller.CoyoteTimer -= dt
+	}
+	if !controller.JumpRequested {
+		return
+	}
+	if controller.IsGrounded() || controller.CoyoteTimer > 0 {
+		physics.Vel.Y = -controller.JumpSpeed
+		controller.JumpRequested = false
+		controller.CoyoteTimer = 0
+		controller.Contacts &^= entity.ContactBelow
+		controller.Ground = nil
+		return
+	}
+	if controller.JumpTimer -= dt; controller.JumpTimer <= 0 {
+		controller.JumpRequested = false
+	}
+}
+
+// move moves the character one axis at a time. When it hits a solid, it is placed against it and slides
+// along the other axis
+func move(position *entity.PositionComponent, physics *entity.PhysicsComponent, collision *entity.CollisionComponent,
+	controller *entity.CharacterControllerComponent, solids []solid, dt float32) {
+	pos := math.ConvertPointToFPoint(position.Pos)
+	if physics.FuturePos != nil {
+		pos = &math.FPoint{X: physics.FuturePos.X, Y: physics.FuturePos.Y}
+	}
+	// box of the character relative to its position
+	box := collision.CollisionAreas[0]
+	for _, area := range collision.CollisionAreas[1:] {
+		box = box.Union(&area)
+	}
+
+	delta := math.FPoint{X: physics.Vel.X * dt, Y: physics.Vel.Y * dt}
+	// the character is carried by the ground it's standing on
+	if controller.Ground != nil {
+		if component := controller.Ground.GetComponent(&entity.PhysicsComponent{}); component != nil {
+			ground := component.(*entity.PhysicsComponent)
+			if ground.Vel != nil {
+				delta.X += ground.Vel.X * dt
+				delta.Y += ground.Vel.Y * dt
+			}
+		}
+	}
+
+	// horizontal movement
+	pos.X += delta.X
+	for _, s := range solids {
+		if s.oneWay || !blocks(s, position, collision) {
+			continue
+		}
+		for _, rect := range s.rects {
+			if !overlapsBox(pos, box, rect) {
+				continue
+			}
+			if delta.X > 0 {
+				pos.X = float32(rect.X - box.X - box.W)
+			} else if delta.X < 0 {
+				pos.X = float32(rect.X + rect.W - box.X)
+			}
+			physics.Vel.X = 0
+		}
+	}
+
+	// vertical movement
+	bottom := pos.Y + float32(box.Y+box.H)
+	pos.Y += delta.Y
+	for _, s := range solids {
+		if !blocks(s, position, collision) {
+			continue
+		}
+		for _, rect := range s.rects {
+			if !overlapsBox(pos, box, rect) {
+				continue
+			}
+			if delta.Y > 0 {
+				// one way platforms only block the characters that were above them
+				if s.oneWay && bottom > float32(rect.Y) {
+					continue
+				}
+				pos.Y = float32(rect.Y - box.Y - box.H)
+				physics.Vel.Y = 0
+			} else if delta.Y < 0 && !s.oneWay {
+				pos.Y = float32(rect.Y + rect.H - box.Y)
+				physics.Vel.Y = 0
+			}
+		}
+	}
+
+	physics.FuturePos = pos
+	syncPosition(position, physics)
+	touch(pos, box, position, collision, controller, solids)
+}
+
+// blocks checks if the solid blocks the character. It must be in the same Z plane and collide with the character
+func blocks(s solid, position *entity.PositionComponent, collision *entity.CollisionComponent) bool {
+	return s.z == position.Z && collision.CollidesWith(s.collision)
+}
+
+// touch updates the contacts of the character with the solids it is touching
+func touch(pos *math.FPoint, box sdl.Rect, position *entity.PositionComponent, collision *entity.CollisionComponent,
+	controller *entity.CharacterControllerComponent, solids []solid) {
+	const epsilon = 0.01
+	near := func(a float32, b int32) bool {
+		return a-float32(b) < epsilon && float32(b)-a < epsilon
+	}
+	controller.Contacts = 0
+	controller.Ground = nil
+	left := pos.X + float32(box.X)
+	right := left + float32(box.W)
+	top := pos.Y + float32(box.Y)
+	bottom := top + float32(box.H)
+	for _, s := range solids {
+		if !blocks(s, position, collision) {
+			continue
+		}
+		for _, rect := range s.rects {
+			overlapX := left < float32(rect.X+rect.W) && float32(rect.X) < right
+			overlapY := top < float32(rect.Y+rect.H) && float32(rect.Y) < bottom
+			if overlapX && near(bottom, rect.Y) {
+				controller.Contacts |= entity.ContactBelow
+				controller.Ground = s.obj
+			}
+			if s.oneWay {
+				continue
+			}
+			if overlapX && near(top, rect.Y+rect.H) {
+				controller.Contacts |= entity.ContactAbove
+			}
+			if overlapY && near(right, rect.X) {
+				controller.Contacts |= entity.ContactRight
+			}
+			if overlapY && near(left, rect.X+rect.W) {
+				controller.Contacts |= entity.ContactLeft
+			}
+		}
+	}
+}
+
+// overlapsBox checks if the box at the given position overlaps the rectangle
+func overlapsBox(pos *math.FPoint, box, rect sdl.Rect) bool {
+	x := pos.X + float32(box.X)
+	y := pos.Y + float32(box.Y)
+	return x < float32(rect.X+rect.W) && float32(rect.X) < x+float32(box.W) &&
+		y < float32(rect.Y+rect.H) && float32(rect.Y) < y+float32(box.H)
+}
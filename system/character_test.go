@@ -0,0 +1,124 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// step runs the physics and character controller systems
+func step(em *entity.Manager, ticks int) {
+	p := &PhysicsSystem{EntityManager: em, Gravity: math.FPoint{X: 0, Y: 1000}}
+	c := &CharacterControllerSystem{EntityManager: em}
+	for i := 0; i < ticks; i++ {
+		p.Update()
+		c.Update()
+	}
+}
+
+func TestCharacterControllerSystem_Ground(t *testing.T) {
+	em := &entity.Manager{}
+	ground := createCollider(em, 0, 100,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 200, H: 10}}})
+	createCollider(em, 50, 0, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 100}}})
+	// pickups don't block the character
+	pickup := entity.RegisterCollisionLayer("test pickup")
+	createCollider(em, 25, 80, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}},
+		Layer: pickup, Mask: pickup})
+	physics := &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 0, Y: 0}, FixedRotation: true}
+	controller := &entity.CharacterControllerComponent{JumpSpeed: 300, CoyoteTime: 0.1, JumpBuffer: 0.1}
+	character := createCollider(em, 0, 0,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}})
+	character.AddComponent(physics)
+	character.AddComponent(controller)
+	physics.Vel = &math.FPoint{X: 200, Y: 0}
+
+	step(em, 50)
+	if !controller.IsGrounded() || controller.Ground != ground {
+		t.Errorf("Character should be on the ground. Contacts: %d", controller.Contacts)
+	}
+	if !controller.OnWall() || physics.Vel.X != 0 {
+		t.Errorf("Character should be stopped by the wall. Contacts: %d, Vel: %v", controller.Contacts, physics.Vel)
+	}
+	if want := (math.FPoint{X: 40, Y: 80}); *physics.FuturePos != want {
+		t.Errorf("FuturePos == %v; want %v", physics.FuturePos, want)
+	}
+
+	controller.Jump()
+	step(em, 1)
+	if controller.IsGrounded() || physics.Vel.Y >= 0 {
+		t.Errorf("Character should be jumping. Contacts: %d, Vel: %v", controller.Contacts, physics.Vel)
+	}
+}
+
+func TestCharacterControllerSystem_CoyoteAndBuffer(t *testing.T) {
+	em := &entity.Manager{}
+	createCollider(em, 0, 100, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}})
+	physics := &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 0, Y: 80}, FixedRotation: true}
+	controller := &entity.CharacterControllerComponent{JumpSpeed: 300, CoyoteTime: 0.1, JumpBuffer: 0.1}
+	character := createCollider(em, 0, 80,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}})
+	character.AddComponent(physics)
+	character.AddComponent(controller)
+	step(em, 1)
+	// walk off the ground. The character is falling, but it can still jump
+	physics.Vel.X = 500
+	step(em, 1)
+	physics.Vel.X = 0
+	step(em, 1)
+	if controller.IsGrounded() {
+		t.Fatal("Character should be falling")
+	}
+	controller.Jump()
+	step(em, 1)
+	if physics.Vel.Y >= 0 {
+		t.Errorf("Character should jump within coyote time. Vel: %v", physics.Vel)
+	}
+
+	// the jump request is kept until the character lands
+	em = &entity.Manager{}
+	createCollider(em, 0, 100, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 20, H: 10}}})
+	physics = &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 0, Y: 78}, FixedRotation: true}
+	controller = &entity.CharacterControllerComponent{JumpSpeed: 300, CoyoteTime: 0.1, JumpBuffer: 0.1}
+	character = createCollider(em, 0, 78,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}})
+	character.AddComponent(physics)
+	character.AddComponent(controller)
+	controller.Jump()
+	step(em, 5)
+	if physics.Vel.Y >= 0 || controller.JumpRequested {
+		t.Errorf("Buffered jump should be executed after landing. Vel: %v", physics.Vel)
+	}
+}
+
+func TestCharacterControllerSystem_Platforms(t *testing.T) {
+	em := &entity.Manager{}
+	platform := createCollider(em, -100, 50, &entity.CollisionComponent{
+		CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 400, H: 10}}, OneWay: true})
+	platform.AddComponent(&entity.PhysicsComponent{BodyType: entity.BodyKinematic,
+		FuturePos: &math.FPoint{X: -100, Y: 50}, Vel: &math.FPoint{X: 50, Y: 0}})
+	physics := &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 0, Y: 70}, FixedRotation: true}
+	controller := &entity.CharacterControllerComponent{JumpSpeed: 300, CoyoteTime: 0.1, JumpBuffer: 0.1}
+	character := createCollider(em, 0, 70,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}})
+	character.AddComponent(physics)
+	character.AddComponent(controller)
+	// jump through the one way platform
+	physics.Vel = &math.FPoint{X: 0, Y: -600}
+	step(em, 5)
+	if controller.OnCeiling() || physics.FuturePos.Y > 30 {
+		t.Fatalf("Character should go through the one way platform. FuturePos: %v", physics.FuturePos)
+	}
+	step(em, 60)
+	if !controller.IsGrounded() || controller.Ground != platform {
+		t.Fatalf("Character should land on the platform. FuturePos: %v", physics.FuturePos)
+	}
+	// the moving platform carries the character
+	x := physics.FuturePos.X
+	step(em, 10)
+	if diff := physics.FuturePos.X - x - 10; diff > 0.01 || diff < -0.01 {
+		t.Errorf("Character moved %f with the platform; want 10", physics.FuturePos.X-x)
+	}
+}
@@ -2,6 +2,7 @@ package system
 
 import (
 	"log"
+	gomath "math"
 
 	"github.com/tubelz/macaw/cmd"
 	"github.com/tubelz/macaw/entity"
@@ -9,20 +10,87 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+const (
+	// BorderNotify notifies border events when the colliders touch the bounds of the world
+	BorderNotify = iota
+	// BorderClamp notifies border events and keeps the colliders inside the bounds of the world
+	BorderClamp
+	// BorderWrap notifies border events and moves the colliders that left the world to the opposite side
+	BorderWrap
+	// BorderDisabled doesn't check the bounds of the world
+	BorderDisabled
+)
+
+// defaultBounds is the bounds of the world if none is set. It's the default window size
+var defaultBounds = sdl.Rect{X: 0, Y: 0, W: 800, H: 600}
+
 // CollisionSystem is the system responsible to handle collisions
 type CollisionSystem struct {
 	EntityManager *entity.Manager
 	Name          string
+	// Broadphase finds the colliders that may collide. A spatial hash is used if none is set
+	Broadphase Broadphase
+	// Bounds of the world used by the border events. If it's empty, the default window size (800x600) is used
+	// and a warning is logged. Use SetBoundsFromWindow or SetBoundsFromCamera when the scene is set up
+	Bounds sdl.Rect
+	// BorderMode defines what happens when the colliders reach the bounds of the world.
+	// (BorderNotify, BorderClamp, BorderWrap or BorderDisabled)
+	BorderMode int
+	colliders  []collider
+	tileMaps   []tileMap
+	contacts   contactTracker
+	// warnedBounds is set once the missing bounds are logged
+	warnedBounds bool
 	Subject
 }
 
+// collider is an entity with position and collision components
+type collider struct {
+	obj       *entity.Entity
+	position  *entity.PositionComponent
+	collision *entity.CollisionComponent
+}
+
 // Init initializes this system. So far it does nothing.
 func (c *CollisionSystem) Init() {}
 
+// SetBoundsFromWindow uses the window size as the bounds of the world
+func (c *CollisionSystem) SetBoundsFromWindow(window *sdl.Window) {
+	w, h := window.GetSize()
+	c.Bounds = sdl.Rect{X: 0, Y: 0, W: w, H: h}
+}
+
+// SetBoundsFromCamera uses the world size of the camera as the bounds of the world
+func (c *CollisionSystem) SetBoundsFromCamera(camera entity.Entitier) {
+	if component := camera.GetComponent(&entity.CameraComponent{}); component != nil {
+		size := component.(*entity.CameraComponent).WorldSize
+		c.Bounds = sdl.Rect{X: 0, Y: 0, W: size.X, H: size.Y}
+	}
+}
+
+// worldBounds returns the bounds of the world, or the default one if it's not set
+func (c *CollisionSystem) worldBounds() sdl.Rect {
+	if c.Bounds.W <= 0 || c.Bounds.H <= 0 {
+		if !c.warnedBounds {
+			c.warnedBounds = true
+			log.Printf("The collision system has no bounds, using the default ones (%dx%d)", defaultBounds.W,
+				defaultBounds.H)
+		}
+		return defaultBounds
+	}
+	return c.Bounds
+}
+
 // Update check for collision and notify observers
 func (c *CollisionSystem) Update() {
 	var component interface{}
 
+	if c.Broadphase == nil {
+		c.Broadphase = &SpatialHash{}
+	}
+	c.Broadphase.Clear()
+	c.colliders = c.colliders[:0]
+
 	requiredComponents := []entity.Component{&entity.PositionComponent{},
 		&entity.CollisionComponent{}}
 	it := c.EntityManager.IterFilter(requiredComponents, -1)
@@ -31,26 +99,89 @@ func (c *CollisionSystem) Update() {
 		position := component.(*entity.PositionComponent)
 		component := obj.GetComponent(&entity.CollisionComponent{})
 		collision := component.(*entity.CollisionComponent)
+		if box, ok := bounds(obj, position, collision); ok {
+			c.Broadphase.Insert(len(c.colliders), box)
+		}
+		c.colliders = append(c.colliders, collider{obj, position, collision})
+	}
+
+	c.tileMaps = c.tileMaps[:0]
+	requiredComponents = []entity.Component{&entity.PositionComponent{}, &entity.TileCollisionComponent{}}
+	it = c.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		tiles := obj.GetComponent(&entity.TileCollisionComponent{}).(*entity.TileCollisionComponent)
+		c.tileMaps = append(c.tileMaps, tileMap{obj, position, tiles})
+	}
+
+	pairs := orderedPairs(c.Broadphase.Pairs())
+	p := 0
+	for i, col := range c.colliders {
 		// check collision with border
-		c.checkBorderCollision(obj, position, collision)
+		c.checkBorderCollision(col.obj, col.position, col.collision)
 
-		// check collision with other entities
-		it2 := c.EntityManager.IterFilter(requiredComponents, i)
-		for obj2, i2 := it2(); i2 != -1; obj2, i2 = it2() {
-			if obj == obj2 {
+		// check collision with the tiles
+		for _, m := range c.tileMaps {
+			if (isInSameZPlane(*col.position, *m.position) || ignoresZ(col.collision)) &&
+				m.tiles.CollidesWith(col.collision) {
+				c.checkTileCollision(col, m)
+			}
+		}
+
+		// check collision with the entities found by the broadphase
+		for ; p < len(pairs) && pairs[p].A == i; p++ {
+			col2 := c.colliders[pairs[p].B]
+			if !inSameZPlane(col, col2) || !col.collision.CollidesWith(col2.collision) {
 				continue
 			}
-			component = obj2.GetComponent(&entity.PositionComponent{})
-			position2 := component.(*entity.PositionComponent)
-			component = obj2.GetComponent(&entity.CollisionComponent{})
-			collision2 := component.(*entity.CollisionComponent)
-
-			if isInSameZPlane(*position, *position2) && c.checkCollisionBetweenAreas(position, collision, position2, collision2) {
-				c.NotifyEvent(&CollisionEvent{Ent: obj, With: obj2})
-				c.NotifyEvent(&CollisionEvent{Ent: obj2, With: obj})
+			sensor := col.collision.Sensor || col2.collision.Sensor
+			if event, ok := collide(col, col2); ok {
+				c.notifyCollision(event)
+				if sensor {
+					c.notifyTrigger(col, col2)
+				}
+			} else if !sensor && (col.collision.Continuous || col2.collision.Continuous) {
+				c.checkContinuousCollision(col.obj, col.position, col.collision, col2.obj, col2.position,
+					col2.collision)
 			}
 		}
 	}
+
+	// notify the contacts that ended
+	for _, k := range c.contacts.end() {
+		if k.trigger {
+			c.NotifyEvent(&TriggerEvent{Sensor: k.ent, Ent: k.with, Phase: CollisionExit})
+		} else if k.with == nil {
+			c.NotifyEvent(&BorderEvent{Ent: k.ent, Side: k.side, Phase: CollisionExit})
+		} else {
+			c.NotifyEvent(&CollisionEvent{Ent: k.ent, With: k.with, Phase: CollisionExit})
+			c.NotifyEvent(&CollisionEvent{Ent: k.with, With: k.ent, Phase: CollisionExit})
+		}
+	}
+}
+
+// bounds returns the box containing all the collision areas and shapes of the object. The box also contains
+// them in the previous position, so continuous collisions are found, and is one pixel larger on each side,
+// so objects touching each other are also found. It returns false if the object has nothing to collide
+func bounds(obj *entity.Entity, position *entity.PositionComponent,
+	collision *entity.CollisionComponent) (sdl.Rect, bool) {
+	rects := collisionRects(obj, position, collision)
+	if len(rects) == 0 {
+		return sdl.Rect{}, false
+	}
+	start, _ := motion(obj, position)
+	dx, dy := start.X-position.Pos.X, start.Y-position.Pos.Y
+	var box sdl.Rect
+	for i, rect := range rects {
+		if i == 0 {
+			box = rect
+		} else {
+			box = box.Union(&rect)
+		}
+		rect = sdl.Rect{X: rect.X + dx, Y: rect.Y + dy, W: rect.W, H: rect.H}
+		box = box.Union(&rect)
+	}
+	return sdl.Rect{X: box.X - 1, Y: box.Y - 1, W: box.W + 2, H: box.H + 2}, true
 }
 
 // isInSameZPlane checks if both objects are in the same Z plane
@@ -58,144 +189,512 @@ func isInSameZPlane(pos1 entity.PositionComponent, pos2 entity.PositionComponent
 	return pos1.Z == pos2.Z
 }
 
-func (c *CollisionSystem) checkCollisionBetweenAreas(pos1 *entity.PositionComponent,
-	col1 *entity.CollisionComponent,
-	pos2 *entity.PositionComponent,
-	col2 *entity.CollisionComponent) bool {
+// inSameZPlane checks if the colliders are in the same Z plane. Sensors with IgnoreZ are in every plane
+func inSameZPlane(col1, col2 collider) bool {
+	return isInSameZPlane(*col1.position, *col2.position) || ignoresZ(col1.collision) || ignoresZ(col2.collision)
+}
+
+// ignoresZ checks if the collider is a sensor ignoring the Z plane
+func ignoresZ(collision *entity.CollisionComponent) bool {
+	return collision.Sensor && collision.IgnoreZ
+}
+
+// collide checks the collision between the areas and shapes of both colliders. It returns the collision event with
+// the contact of the first pair of areas (or shapes) found colliding
+func collide(col1, col2 collider) (*CollisionEvent, bool) {
+	if len(col1.collision.Shapes) > 0 || len(col2.collision.Shapes) > 0 {
+		shapes2 := collisionShapes(col2.obj, col2.position, col2.collision)
+		for i, shape1 := range collisionShapes(col1.obj, col1.position, col1.collision) {
+			for j, shape2 := range shapes2 {
+				if shapesCollide(shape1, shape2) {
+					return newCollisionEvent(col1.obj, col2.obj, i, j, shape1, shape2), true
+				}
+			}
+		}
+		return nil, false
+	}
+
+	pos1, pos2 := col1.position.Pos, col2.position.Pos
 	var rect1, rect2 *sdl.Rect
-	for _, area1 := range col1.CollisionAreas {
-		rect1 = &sdl.Rect{X: pos1.Pos.X + area1.X, Y: pos1.Pos.Y + area1.Y, W: area1.W, H: area1.H}
-		for _, area2 := range col2.CollisionAreas {
-			rect2 = &sdl.Rect{X: pos2.Pos.X + area2.X, Y: pos2.Pos.Y + area2.Y, W: area2.W, H: area2.H}
-			if rect1.HasIntersection(rect2) {
-				return true
+	for i, area1 := range col1.collision.CollisionAreas {
+		rect1 = &sdl.Rect{X: pos1.X + area1.X, Y: pos1.Y + area1.Y, W: area1.W, H: area1.H}
+		for j, area2 := range col2.collision.CollisionAreas {
+			rect2 = &sdl.Rect{X: pos2.X + area2.X, Y: pos2.Y + area2.Y, W: area2.W, H: area2.H}
+			if !rect1.HasIntersection(rect2) {
+				continue
+			}
+			event := newCollisionEvent(col1.obj, col2.obj, i, j, rectShape(*rect1), rectShape(*rect2))
+			if col1.collision.PixelMask == nil && col2.collision.PixelMask == nil {
+				return event, true
+			}
+			overlap, _ := rect1.Intersect(rect2)
+			if point, ok := masksOverlap(col1, col2, overlap); ok {
+				event.Points = []math.FPoint{point}
+				return event, true
 			}
 		}
 	}
 
-	return false
+	return nil, false
+}
+
+// newCollisionEvent creates the collision event with the contact between the shapes
+func newCollisionEvent(obj1, obj2 *entity.Entity, area1, area2 int, shape1, shape2 convexShape) *CollisionEvent {
+	normal, depth, points := manifold(shape1, shape2)
+	return &CollisionEvent{Ent: obj1, With: obj2, Normal: normal, Depth: depth, Points: points, Area: area1,
+		WithArea: area2}
+}
+
+// checkContinuousCollision sweeps the collision areas from the previous position to the current position.
+// If they hit each other, the objects are stopped at the surface and the observers are notified
+func (c *CollisionSystem) checkContinuousCollision(obj1 *entity.Entity, pos1 *entity.PositionComponent,
+	col1 *entity.CollisionComponent, obj2 *entity.Entity, pos2 *entity.PositionComponent,
+	col2 *entity.CollisionComponent) {
+	start1, motion1 := motion(obj1, pos1)
+	start2, motion2 := motion(obj2, pos2)
+	// we sweep the first object using the relative motion, so the second object can be seen as static
+	relMotion := math.FPoint{X: motion1.X - motion2.X, Y: motion1.Y - motion2.Y}
+	if relMotion == (math.FPoint{}) {
+		return
+	}
+	var normal math.FPoint
+	var points []math.FPoint
+	var area1, area2 int
+	toi := float32(1)
+	hit := false
+	// circles are swept as circles, the other shapes as their bounding boxes
+	shapes2 := collisionShapes(obj2, &entity.PositionComponent{Pos: &start2}, col2)
+	for i, shape1 := range collisionShapes(obj1, &entity.PositionComponent{Pos: &start1}, col1) {
+		for j, shape2 := range shapes2 {
+			var t float32
+			var n math.FPoint
+			var ok bool
+			c1, r1, circle1 := shape1.circle()
+			c2, r2, circle2 := shape2.circle()
+			if circle1 && circle2 {
+				t, n, ok = math.SweepCircle(c1, r1, relMotion, c2, r2)
+			} else {
+				t, n, ok = math.SweepRect(shape1.box(), relMotion, shape2.box())
+			}
+			if ok && t <= toi {
+				toi, normal, hit = t, n, true
+				area1, area2 = i, j
+				// the second object also moved until the time of impact
+				offset := math.FPoint{X: motion2.X * t, Y: motion2.Y * t}
+				points = sweepPoints(shape1, shape2, relMotion, t, normal, offset)
+			}
+		}
+	}
+	if !hit {
+		return
+	}
+	opposite := math.FPoint{X: -normal.X, Y: -normal.Y}
+	stopAt(obj1, pos1, start1, motion1, toi, normal)
+	stopAt(obj2, pos2, start2, motion2, toi, opposite)
+	c.notifyCollision(&CollisionEvent{Ent: obj1, With: obj2, TimeOfImpact: toi, Normal: normal, Points: points,
+		Area: area1, WithArea: area2})
+}
+
+// motion returns where the object was in the previous tick and how much it moved since then
+func motion(obj *entity.Entity, position *entity.PositionComponent) (sdl.Point, math.FPoint) {
+	component := obj.GetComponent(&entity.PhysicsComponent{})
+	if component == nil {
+		return *position.Pos, math.FPoint{}
+	}
+	physics := component.(*entity.PhysicsComponent)
+	if physics.FuturePos == nil || physics.PrevPos == nil {
+		return *position.Pos, math.FPoint{}
+	}
+	start := sdl.Point{X: math.Round(physics.PrevPos.X), Y: math.Round(physics.PrevPos.Y)}
+	return start, math.FPoint{X: physics.FuturePos.X - float32(start.X), Y: physics.FuturePos.Y - float32(start.Y)}
 }
 
-// BorderEvent has the entity (Ent) that transpassed the border and which border
+// stopAt moves the object to the position at the time of impact.
+// The position is rounded away from the surface (normal), so the objects don't overlap
+func stopAt(obj *entity.Entity, position *entity.PositionComponent, start sdl.Point, motion math.FPoint,
+	toi float32, normal math.FPoint) {
+	if motion == (math.FPoint{}) {
+		return
+	}
+	physics := obj.GetComponent(&entity.PhysicsComponent{}).(*entity.PhysicsComponent)
+	x := float64(float32(start.X) + motion.X*toi)
+	y := float64(float32(start.Y) + motion.Y*toi)
+	if normal.X > 0 {
+		x = gomath.Floor(x)
+	} else if normal.X < 0 {
+		x = gomath.Ceil(x)
+	}
+	if normal.Y > 0 {
+		y = gomath.Floor(y)
+	} else if normal.Y < 0 {
+		y = gomath.Ceil(y)
+	}
+	physics.FuturePos = &math.FPoint{X: float32(x), Y: float32(y)}
+	syncPosition(position, physics)
+}
+
+// BorderEvent has the entity (Ent) that transpassed the border and which border.
+// Phase tells if the contact with the border began, continues or ended. See CollisionEnter
 type BorderEvent struct {
-	Ent  *entity.Entity
-	Side string
+	BaseEvent
+	Ent   *entity.Entity
+	Side  string
+	Phase int
 }
 
-// Name returns the border event name
+// Name returns the border event name. Each phase has its own name
 func (b *BorderEvent) Name() string {
+	switch b.Phase {
+	case CollisionEnter:
+		return "border enter"
+	case CollisionStay:
+		return "border stay"
+	case CollisionExit:
+		return "border exit"
+	}
 	return "border event"
 }
 
+// notifyBorder notifies the border event every tick and the phase of the contact with the border
+func (c *CollisionSystem) notifyBorder(obj *entity.Entity, side string) {
+	c.NotifyEvent(&BorderEvent{Ent: obj, Side: side})
+	if phase, ok := c.contacts.touch(contactKey{ent: obj, side: side}); ok {
+		c.NotifyEvent(&BorderEvent{Ent: obj, Side: side, Phase: phase})
+	}
+}
+
 func (c *CollisionSystem) checkBorderCollision(obj *entity.Entity,
 	position *entity.PositionComponent,
 	collision *entity.CollisionComponent) {
-	for _, area := range collision.CollisionAreas {
-		// check each side. top and left don't require collision size
-		if position.Pos.X+area.W > 799 {
-			c.NotifyEvent(&BorderEvent{Ent: obj, Side: "right"})
-		} else if position.Pos.X < 1 {
-			c.NotifyEvent(&BorderEvent{Ent: obj, Side: "left"})
+	if c.BorderMode == BorderDisabled {
+		return
+	}
+	world := c.worldBounds()
+	for _, rect := range collisionRects(obj, position, collision) {
+		// check each side. The areas touching the border also notify
+		x, y := rect.X, rect.Y
+		if x+rect.W >= world.X+world.W {
+			c.notifyBorder(obj, "right")
+		} else if x <= world.X {
+			c.notifyBorder(obj, "left")
 		}
 
-		if position.Pos.Y < 1 {
-			c.NotifyEvent(&BorderEvent{Ent: obj, Side: "top"})
-		} else if position.Pos.Y+area.H > 599 {
-			c.NotifyEvent(&BorderEvent{Ent: obj, Side: "bottom"})
+		if y <= world.Y {
+			c.notifyBorder(obj, "top")
+		} else if y+rect.H >= world.Y+world.H {
+			c.notifyBorder(obj, "bottom")
+		}
+	}
+
+	switch c.BorderMode {
+	case BorderClamp:
+		clamp(obj, position, collision, world)
+	case BorderWrap:
+		wrap(obj, position, collision, world)
+	}
+}
+
+// areasBox returns the box containing all the collision areas and shapes in the world
+func areasBox(obj *entity.Entity, position *entity.PositionComponent, collision *entity.CollisionComponent) sdl.Rect {
+	var box sdl.Rect
+	for i, rect := range collisionRects(obj, position, collision) {
+		if i == 0 {
+			box = rect
+		} else {
+			box = box.Union(&rect)
 		}
 	}
+	return box
 }
 
-// CollisionEvent has the entity (Ent) that produced the collision and the entity that got collided (With)
+// clamp moves the collider back inside the world. Its velocity towards the border is removed
+func clamp(obj *entity.Entity, position *entity.PositionComponent, collision *entity.CollisionComponent,
+	world sdl.Rect) {
+	if len(collision.CollisionAreas) == 0 && len(collision.Shapes) == 0 {
+		return
+	}
+	box := areasBox(obj, position, collision)
+	var dx, dy int32
+	if box.X < world.X {
+		dx = world.X - box.X
+	} else if box.X+box.W > world.X+world.W {
+		dx = world.X + world.W - box.X - box.W
+	}
+	if box.Y < world.Y {
+		dy = world.Y - box.Y
+	} else if box.Y+box.H > world.Y+world.H {
+		dy = world.Y + world.H - box.Y - box.H
+	}
+	if dx == 0 && dy == 0 {
+		return
+	}
+	physics := translate(obj, position, math.FPoint{X: float32(dx), Y: float32(dy)})
+	if physics == nil || physics.Vel == nil {
+		return
+	}
+	if float32(dx)*physics.Vel.X < 0 {
+		physics.Vel.X = 0
+	}
+	if float32(dy)*physics.Vel.Y < 0 {
+		physics.Vel.Y = 0
+	}
+}
+
+// wrap moves the collider that is completely outside the world to the opposite side
+func wrap(obj *entity.Entity, position *entity.PositionComponent, collision *entity.CollisionComponent,
+	world sdl.Rect) {
+	if len(collision.CollisionAreas) == 0 && len(collision.Shapes) == 0 {
+		return
+	}
+	box := areasBox(obj, position, collision)
+	var dx, dy int32
+	// only one side is strict, so a collider on the edge doesn't go back and forth between the sides
+	if box.X > world.X+world.W {
+		dx = -world.W - box.W
+	} else if box.X+box.W <= world.X {
+		dx = world.W + box.W
+	}
+	if box.Y > world.Y+world.H {
+		dy = -world.H - box.H
+	} else if box.Y+box.H <= world.Y {
+		dy = world.H + box.H
+	}
+	if dx == 0 && dy == 0 {
+		return
+	}
+	// the previous position is also moved, so the render doesn't interpolate across the world
+	physics := translate(obj, position, math.FPoint{X: float32(dx), Y: float32(dy)})
+	if physics != nil && physics.PrevPos != nil {
+		physics.PrevPos.X += float32(dx)
+		physics.PrevPos.Y += float32(dy)
+	}
+}
+
+// translate moves the object by the given displacement and returns its physics component, if it has one
+func translate(obj *entity.Entity, position *entity.PositionComponent,
+	displacement math.FPoint) *entity.PhysicsComponent {
+	var physics *entity.PhysicsComponent
+	if component := obj.GetComponent(&entity.PhysicsComponent{}); component != nil {
+		physics = component.(*entity.PhysicsComponent)
+	}
+	if physics == nil || physics.FuturePos == nil {
+		position.Pos.X += math.Round(displacement.X)
+		position.Pos.Y += math.Round(displacement.Y)
+		return physics
+	}
+	physics.FuturePos.X += displacement.X
+	physics.FuturePos.Y += displacement.Y
+	syncPosition(position, physics)
+	return physics
+}
+
+// CollisionEvent has the entity (Ent) that produced the collision and the entity that got collided (With).
+// The contact has the normal, pointing from Ent to With, how much they penetrate each other along it (Depth)
+// and the contact points in the world. Area and WithArea are the areas that touched: the index of the
+// collision area, or the number of collision areas plus the index of the shape.
+// Continuous collisions also have the time of impact (fraction of the movement until the hit). They were
+// stopped at the surface, so they don't penetrate.
+// Phase tells if the contact began (CollisionEnter), continues (CollisionStay) or ended (CollisionExit).
+// The events with phase CollisionTick are notified every tick while the entities are colliding.
+// Exit events don't have contact information.
+// Collisions with tile maps are notified as "tile collision" events, only to the collider (Ent), every tick.
+// They have the tile hit (Tile)
 type CollisionEvent struct {
-	Ent  *entity.Entity
-	With *entity.Entity
+	BaseEvent
+	Ent          *entity.Entity
+	With         *entity.Entity
+	Normal       math.FPoint
+	Depth        float32
+	Points       []math.FPoint
+	Area         int
+	WithArea     int
+	TimeOfImpact float32
+	Phase        int
+	Tile         *TileContact
 }
 
-// Name returns the collision event name
+// Name returns the collision event name. Each phase has its own name
 func (c *CollisionEvent) Name() string {
+	if c.Tile != nil {
+		return "tile collision"
+	}
+	switch c.Phase {
+	case CollisionEnter:
+		return "collision enter"
+	case CollisionStay:
+		return "collision stay"
+	case CollisionExit:
+		return "collision exit"
+	}
 	return "collision event"
 }
 
+// reverse returns the same collision from the point of view of the other entity
+func (c *CollisionEvent) reverse() *CollisionEvent {
+	reverse := *c
+	reverse.Ent, reverse.With = c.With, c.Ent
+	reverse.Area, reverse.WithArea = c.WithArea, c.Area
+	reverse.Normal = math.FPoint{X: -c.Normal.X, Y: -c.Normal.Y}
+	reverse.BaseEvent = BaseEvent{}
+	return &reverse
+}
+
+// notifyCollision notifies the collision event in both directions every tick, and the phase of the contact
+func (c *CollisionSystem) notifyCollision(event *CollisionEvent) {
+	reverse := event.reverse()
+	c.NotifyEvent(event)
+	c.NotifyEvent(reverse)
+	if phase, ok := c.contacts.touch(pairContact(event.Ent, event.With)); ok {
+		enter, reverseEnter := *event, *reverse
+		enter.Phase, reverseEnter.Phase = phase, phase
+		enter.BaseEvent, reverseEnter.BaseEvent = BaseEvent{}, BaseEvent{}
+		c.NotifyEvent(&enter)
+		c.NotifyEvent(&reverseEnter)
+	}
+}
+
+// notifyTrigger notifies the sensors of the pair that the other collider entered them
+func (c *CollisionSystem) notifyTrigger(col1, col2 collider) {
+	for _, pair := range [][2]collider{{col1, col2}, {col2, col1}} {
+		sensor, obj := pair[0], pair[1]
+		if !sensor.collision.Sensor {
+			continue
+		}
+		if phase, ok := c.contacts.touch(triggerContact(sensor.obj, obj.obj)); ok && phase == CollisionEnter {
+			c.NotifyEvent(&TriggerEvent{Sensor: sensor.obj, Ent: obj.obj, Phase: CollisionEnter})
+		}
+	}
+}
+
+// TriggerEvent is notified when an entity (Ent) enters (CollisionEnter) or exits (CollisionExit) a sensor
+type TriggerEvent struct {
+	BaseEvent
+	Sensor *entity.Entity
+	Ent    *entity.Entity
+	Phase  int
+}
+
+// Name returns the trigger event name: "trigger enter" or "trigger exit"
+func (t *TriggerEvent) Name() string {
+	if t.Phase == CollisionExit {
+		return "trigger exit"
+	}
+	return "trigger enter"
+}
+
 /*
 	----
 	Util functions for handling collision events
 	----
 */
 
-// InvertVel invert the vel of the collided object.
+// InvertVel inverts the velocity of the collided object (Ent) along the normal of the contact, and moves it out
+// of the other object.
 func InvertVel(event Event) {
 	collision := event.(*CollisionEvent)
+	if isSensorCollision(collision) {
+		return
+	}
 	if cmd.Parser.Debug() {
 		log.Printf("Inverting pos and mov of obj %d", collision.Ent.GetID())
 	}
 
-	component := collision.Ent.GetComponent(&entity.PositionComponent{})
-	position := component.(*entity.PositionComponent)
+	physics := movingBody(collision.Ent)
+	if physics == nil {
+		return
+	}
+	if collision.Normal.X != 0 {
+		physics.Vel.X *= -1
+		if physics.Acc != nil {
+			physics.Acc.X *= -1
+		}
+	}
+	if collision.Normal.Y != 0 {
+		physics.Vel.Y *= -1
+		if physics.Acc != nil {
+			physics.Acc.Y *= -1
+		}
+	}
+	PushOut(event)
+}
+
+// Bounce reflects the velocity of the collided object (Ent) on the surface, keeping its speed,
+// and moves it out of the other object.
+func Bounce(event Event) {
+	collision := event.(*CollisionEvent)
+	if isSensorCollision(collision) {
+		return
+	}
+	if physics := movingBody(collision.Ent); physics != nil {
+		// only if it's moving towards the surface
+		if v := math.Dot(*physics.Vel, collision.Normal); v > 0 {
+			physics.Vel.X -= 2 * v * collision.Normal.X
+			physics.Vel.Y -= 2 * v * collision.Normal.Y
+		}
+	}
+	PushOut(event)
+}
 
-	if component = collision.Ent.GetComponent(&entity.PhysicsComponent{}); component == nil {
+// Slide removes the velocity of the collided object (Ent) towards the surface, so it slides along it,
+// and moves it out of the other object.
+func Slide(event Event) {
+	collision := event.(*CollisionEvent)
+	if isSensorCollision(collision) {
 		return
 	}
-	physics := component.(*entity.PhysicsComponent)
+	if physics := movingBody(collision.Ent); physics != nil {
+		if v := math.Dot(*physics.Vel, collision.Normal); v > 0 {
+			physics.Vel.X -= v * collision.Normal.X
+			physics.Vel.Y -= v * collision.Normal.Y
+		}
+	}
+	PushOut(event)
+}
 
-	intersectRect := intersection(collision.Ent, collision.With)
-	displacementPos := &sdl.Point{X: intersectRect.W, Y: intersectRect.H}
+// Stop stops the collided object (Ent) and moves it out of the other object.
+func Stop(event Event) {
+	collision := event.(*CollisionEvent)
+	if isSensorCollision(collision) {
+		return
+	}
+	if physics := movingBody(collision.Ent); physics != nil {
+		physics.Vel.X, physics.Vel.Y = 0, 0
+	}
+	PushOut(event)
+}
 
-	// TODO: Clean this a little bit...
-	if displacementPos.X < displacementPos.Y {
-		physics.Vel.X *= -1
-		physics.Acc.X *= -1
-		if physics.Vel.X > 0 {
-			position.Pos.X = position.Pos.X + displacementPos.X
-		} else if physics.Vel.X < 0 {
-			position.Pos.X = position.Pos.X - displacementPos.X
-		}
-		physics.FuturePos.X = float32(position.Pos.X) + physics.Vel.X
-	} else if displacementPos.Y < displacementPos.X {
-		physics.Vel.Y *= -1
-		physics.Acc.Y *= -1
-		if physics.Vel.Y > 0 {
-			position.Pos.Y = position.Pos.Y + displacementPos.Y
-		} else if physics.Vel.Y < 0 {
-			position.Pos.Y = position.Pos.Y - displacementPos.Y
-		}
-		physics.FuturePos.Y = float32(position.Pos.Y) + physics.Vel.Y
-	} else {
-		physics.Vel = math.MulFPointWithFloat(physics.Vel, -1)
-		physics.Acc = math.MulFPointWithFloat(physics.Acc, -1)
-		if physics.Vel.X > 0 {
-			position.Pos.X = position.Pos.X + displacementPos.X
-		} else if physics.Vel.X < 0 {
-			position.Pos.X = position.Pos.X - displacementPos.X
-		}
-		if physics.Vel.Y > 0 {
-			position.Pos.Y = position.Pos.Y + displacementPos.Y
-		} else if physics.Vel.Y < 0 {
-			position.Pos.Y = position.Pos.Y - displacementPos.Y
-		}
-		physics.FuturePos = math.ConvertPointToFPoint(math.SumPointWithFPoint(position.Pos, physics.Vel))
-	}
-}
-
-// intersection get the intersection rectangle between two objects
-func intersection(obj1, obj2 *entity.Entity) sdl.Rect {
-	posComp := &entity.PositionComponent{}
-	position1 := obj1.GetComponent(posComp).(*entity.PositionComponent)
-	position2 := obj2.GetComponent(posComp).(*entity.PositionComponent)
-
-	colComp := &entity.CollisionComponent{}
-	collision1 := obj1.GetComponent(colComp).(*entity.CollisionComponent)
-	collision2 := obj2.GetComponent(colComp).(*entity.CollisionComponent)
-
-	for _, area1 := range collision1.CollisionAreas {
-		rect1 := &sdl.Rect{X: position1.Pos.X + area1.X, Y: position1.Pos.Y + area1.Y, W: area1.W, H: area1.H}
-		for _, area2 := range collision2.CollisionAreas {
-			rect2 := &sdl.Rect{X: position2.Pos.X + area2.X, Y: position2.Pos.Y + area2.Y, W: area2.W, H: area2.H}
-			if displacement, ok := rect1.Intersect(rect2); ok {
-				return displacement
-			}
+// PushOut moves the collided object (Ent) out of the other object, along the normal of the contact.
+// Only Ent is moved, so the handler should be used for the object that moves.
+func PushOut(event Event) {
+	collision := event.(*CollisionEvent)
+	if collision.Depth <= 0 || isSensorCollision(collision) {
+		return
+	}
+	position := collision.Ent.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+	translate(collision.Ent, position, math.FPoint{X: -collision.Normal.X * collision.Depth,
+		Y: -collision.Normal.Y * collision.Depth})
+}
+
+// isSensorCollision checks if one of the entities is a sensor. Sensors don't take part in the collision response
+func isSensorCollision(collision *CollisionEvent) bool {
+	for _, obj := range []*entity.Entity{collision.Ent, collision.With} {
+		if component := obj.GetComponent(&entity.CollisionComponent{}); component != nil &&
+			component.(*entity.CollisionComponent).Sensor {
+			return true
 		}
 	}
+	return false
+}
 
-	return sdl.Rect{0, 0, 0, 0}
+// movingBody returns the physics component of the object if it has velocity
+func movingBody(obj *entity.Entity) *entity.PhysicsComponent {
+	component := obj.GetComponent(&entity.PhysicsComponent{})
+	if component == nil {
+		return nil
+	}
+	physics := component.(*entity.PhysicsComponent)
+	if physics.Vel == nil {
+		return nil
+	}
+	return physics
 }
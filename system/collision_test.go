@@ -0,0 +1,300 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCollisionSystem_Continuous(t *testing.T) {
+	cases := []struct {
+		name       string
+		continuous bool
+		wantPos    math.FPoint
+		wantEvents int
+	}{
+		{"tunneling", false, math.FPoint{X: 200, Y: 100}, 0},
+		{"continuous", true, math.FPoint{X: 90, Y: 100}, 2},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		// the physics system already moved the ball from x=0 to x=200 in this tick
+		ball := createCollider(em, 200, 100, &entity.CollisionComponent{
+			CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}, Continuous: c.continuous})
+		physics := &entity.PhysicsComponent{
+			PrevPos:   &math.FPoint{X: 0, Y: 100},
+			FuturePos: &math.FPoint{X: 200, Y: 100},
+			Vel:       &math.FPoint{X: 10000},
+		}
+		ball.AddComponent(physics)
+		paddle := createCollider(em, 100, 50,
+			&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 5, H: 100}}})
+
+		var events []*CollisionEvent
+		cs := &CollisionSystem{EntityManager: em}
+		cs.AddHandler("collision event", func(e Event) {
+			events = append(events, e.(*CollisionEvent))
+		})
+		cs.Update()
+
+		if *physics.FuturePos != c.wantPos {
+			t.Errorf("%s: FuturePos == %v; want %v", c.name, physics.FuturePos, c.wantPos)
+		}
+		position := ball.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		if position.Pos.X != int32(c.wantPos.X) || position.Pos.Y != int32(c.wantPos.Y) {
+			t.Errorf("%s: Pos == %v; want %v", c.name, position.Pos, c.wantPos)
+		}
+		if len(events) != c.wantEvents {
+			t.Fatalf("%s: %d events; want %d", c.name, len(events), c.wantEvents)
+		}
+		if c.wantEvents == 0 {
+			continue
+		}
+		if events[0].Ent != ball || events[0].With != paddle || events[0].Normal != (math.FPoint{X: 1, Y: 0}) {
+			t.Errorf("%s: wrong event %v", c.name, events[0])
+		}
+		InvertVel(events[0])
+		if physics.Vel.X != -10000 {
+			t.Errorf("%s: Vel.X == %f after InvertVel; want -10000", c.name, physics.Vel.X)
+		}
+	}
+}
+
+func TestCollisionSystem_Layers(t *testing.T) {
+	player := entity.RegisterCollisionLayer("test player")
+	bullet := entity.RegisterCollisionLayer("test player bullet")
+	enemy := entity.RegisterCollisionLayer("test enemy")
+	em := &entity.Manager{}
+	areas := []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}
+	p := createCollider(em, 100, 100, &entity.CollisionComponent{CollisionAreas: areas, Layer: player})
+	b := createCollider(em, 100, 100, &entity.CollisionComponent{CollisionAreas: areas, Layer: bullet, Mask: enemy})
+	e := createCollider(em, 105, 100, &entity.CollisionComponent{CollisionAreas: areas, Layer: enemy})
+
+	var events [][2]*entity.Entity
+	cs := &CollisionSystem{EntityManager: em}
+	cs.AddHandler("collision event", func(ev Event) {
+		event := ev.(*CollisionEvent)
+		events = append(events, [2]*entity.Entity{event.Ent, event.With})
+	})
+	cs.Update()
+
+	// the bullet doesn't hit the player
+	want := [][2]*entity.Entity{{p, e}, {e, p}, {b, e}, {e, b}}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events == %v; want %v", events, want)
+	}
+}
+
+func TestCollisionSystem_Border(t *testing.T) {
+	area := sdl.Rect{X: 0, Y: 0, W: 10, H: 10}
+	cases := []struct {
+		name      string
+		bounds    sdl.Rect
+		mode      int
+		pos       sdl.Point
+		wantSides []string
+		wantPos   sdl.Point
+	}{
+		{"default bounds", sdl.Rect{}, BorderNotify, sdl.Point{X: 795, Y: 300}, []string{"right"},
+			sdl.Point{X: 795, Y: 300}},
+		{"inside", sdl.Rect{}, BorderNotify, sdl.Point{X: 100, Y: 100}, nil, sdl.Point{X: 100, Y: 100}},
+		{"bigger world", sdl.Rect{X: 0, Y: 0, W: 2000, H: 1000}, BorderNotify, sdl.Point{X: 795, Y: 995},
+			[]string{"bottom"}, sdl.Point{X: 795, Y: 995}},
+		{"disabled", sdl.Rect{}, BorderDisabled, sdl.Point{X: -5, Y: 300}, nil, sdl.Point{X: -5, Y: 300}},
+		{"clamp", sdl.Rect{X: 0, Y: 0, W: 200, H: 100}, BorderClamp, sdl.Point{X: -5, Y: 95},
+			[]string{"left", "bottom"}, sdl.Point{X: 0, Y: 90}},
+		{"wrap", sdl.Rect{X: 0, Y: 0, W: 200, H: 100}, BorderWrap, sdl.Point{X: 203, Y: 50},
+			[]string{"right"}, sdl.Point{X: -7, Y: 50}},
+		{"no wrap", sdl.Rect{X: 0, Y: 0, W: 200, H: 100}, BorderWrap, sdl.Point{X: 195, Y: 50},
+			[]string{"right"}, sdl.Point{X: 195, Y: 50}},
+		{"wrap from the left edge", sdl.Rect{X: 0, Y: 0, W: 200, H: 100}, BorderWrap, sdl.Point{X: -10, Y: 50},
+			[]string{"left"}, sdl.Point{X: 200, Y: 50}},
+		{"on the right edge", sdl.Rect{X: 0, Y: 0, W: 200, H: 100}, BorderWrap, sdl.Point{X: 200, Y: 50},
+			[]string{"right"}, sdl.Point{X: 200, Y: 50}},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		obj := createCollider(em, c.pos.X, c.pos.Y, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{area}})
+		physics := &entity.PhysicsComponent{FuturePos: math.ConvertPointToFPoint(&c.pos),
+			Vel: &math.FPoint{X: -10, Y: 10}}
+		obj.AddComponent(physics)
+
+		var sides []string
+		cs := &CollisionSystem{EntityManager: em, Bounds: c.bounds, BorderMode: c.mode}
+		cs.AddHandler("border event", func(e Event) {
+			sides = append(sides, e.(*BorderEvent).Side)
+		})
+		cs.Update()
+
+		if !reflect.DeepEqual(sides, c.wantSides) {
+			t.Errorf("%s: sides == %v; want %v", c.name, sides, c.wantSides)
+		}
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		if *position.Pos != c.wantPos {
+			t.Errorf("%s: Pos == %v; want %v", c.name, position.Pos, c.wantPos)
+		}
+		if physics.FuturePos.X != float32(c.wantPos.X) || physics.FuturePos.Y != float32(c.wantPos.Y) {
+			t.Errorf("%s: FuturePos == %v; want %v", c.name, physics.FuturePos, c.wantPos)
+		}
+	}
+}
+
+func TestCollisionSystem_SetBoundsFromCamera(t *testing.T) {
+	em := &entity.Manager{}
+	camera := em.Create("camera")
+	camera.AddComponent(&entity.CameraComponent{ViewportSize: sdl.Point{X: 800, Y: 600},
+		WorldSize: sdl.Point{X: 3000, Y: 900}})
+	cs := &CollisionSystem{EntityManager: em}
+	cs.SetBoundsFromCamera(camera)
+	if want := (sdl.Rect{X: 0, Y: 0, W: 3000, H: 900}); cs.Bounds != want {
+		t.Errorf("Bounds == %v; want %v", cs.Bounds, want)
+	}
+}
+
+func TestCollisionSystem_Phases(t *testing.T) {
+	em := &entity.Manager{}
+	area := sdl.Rect{X: 0, Y: 0, W: 10, H: 10}
+	a := createCollider(em, 100, 100, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{area}})
+	b := createCollider(em, 105, 100, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{area}})
+	position := a.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+
+	var names []string
+	cs := &CollisionSystem{EntityManager: em}
+	record := func(e Event) {
+		if event, ok := e.(*CollisionEvent); ok && event.Ent == a && event.With == b {
+			names = append(names, e.Name())
+		}
+		if event, ok := e.(*BorderEvent); ok {
+			names = append(names, e.Name()+" "+event.Side)
+		}
+	}
+	for _, name := range []string{"collision event", "collision enter", "collision stay", "collision exit",
+		"border enter", "border exit"} {
+		cs.AddHandler(name, record)
+	}
+
+	cs.Update()
+	cs.Update()
+	// moving a to the border, away from b
+	position.Pos.X = 0
+	cs.Update()
+	cs.Update()
+	position.Pos.X = 50
+	cs.Update()
+
+	want := []string{
+		"collision event", "collision enter",
+		"collision event", "collision stay",
+		"border enter left", "collision exit",
+		"border exit left",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("events == %v; want %v", names, want)
+	}
+}
+
+func TestCollisionSystem_Manifold(t *testing.T) {
+	em := &entity.Manager{}
+	a := createCollider(em, 0, 0, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 20, Y: 20, W: 5, H: 5}}})
+	collision := a.GetComponent(&entity.CollisionComponent{}).(*entity.CollisionComponent)
+	collision.CollisionAreas = append(collision.CollisionAreas, sdl.Rect{X: 0, Y: 0, W: 10, H: 10})
+	b := createCollider(em, 8, 2, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 6}}})
+	circle := entity.CollisionShape{ShapeType: entity.ShapeCircle, Radius: 10}
+	c := createCollider(em, 100, 100, &entity.CollisionComponent{Shapes: []entity.CollisionShape{circle}})
+	d := createCollider(em, 115, 100, &entity.CollisionComponent{Shapes: []entity.CollisionShape{circle}})
+
+	events := make(map[*entity.Entity]*CollisionEvent)
+	cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+	cs.AddHandler("collision event", func(e Event) {
+		event := e.(*CollisionEvent)
+		events[event.Ent] = event
+	})
+	cs.Update()
+
+	want := []*CollisionEvent{
+		{Ent: a, With: b, Normal: math.FPoint{X: 1}, Depth: 2, Points: []math.FPoint{{X: 8, Y: 2}, {X: 8, Y: 8}},
+			Area: 1, WithArea: 0},
+		{Ent: b, With: a, Normal: math.FPoint{X: -1}, Depth: 2, Points: []math.FPoint{{X: 8, Y: 2}, {X: 8, Y: 8}},
+			Area: 0, WithArea: 1},
+		{Ent: c, With: d, Normal: math.FPoint{X: 1}, Depth: 5, Points: []math.FPoint{{X: 107.5, Y: 100}}},
+		{Ent: d, With: c, Normal: math.FPoint{X: -1}, Depth: 5, Points: []math.FPoint{{X: 107.5, Y: 100}}},
+	}
+	for _, w := range want {
+		if got := events[w.Ent]; !reflect.DeepEqual(got, w) {
+			t.Errorf("event of %d == %+v; want %+v", w.Ent.GetID(), got, w)
+		}
+	}
+}
+
+func TestResponseHelpers(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler func(Event)
+		wantVel math.FPoint
+	}{
+		{"Bounce", Bounce, math.FPoint{X: 10, Y: -10}},
+		{"Slide", Slide, math.FPoint{X: 10, Y: 0}},
+		{"Stop", Stop, math.FPoint{X: 0, Y: 0}},
+		{"PushOut", PushOut, math.FPoint{X: 10, Y: 10}},
+		{"InvertVel", InvertVel, math.FPoint{X: 10, Y: -10}},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		obj := createCollider(em, 0, 0,
+			&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}})
+		physics := &entity.PhysicsComponent{Vel: &math.FPoint{X: 10, Y: 10}, FuturePos: &math.FPoint{}}
+		obj.AddComponent(physics)
+		ground := createCollider(em, 0, 8,
+			&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 100, H: 10}}})
+
+		c.handler(&CollisionEvent{Ent: obj, With: ground, Normal: math.FPoint{Y: 1}, Depth: 2})
+
+		if *physics.Vel != c.wantVel {
+			t.Errorf("%s: Vel == %v; want %v", c.name, *physics.Vel, c.wantVel)
+		}
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		if position.Pos.Y != -2 {
+			t.Errorf("%s: Pos.Y == %d; want -2", c.name, position.Pos.Y)
+		}
+	}
+}
+
+func TestCollisionSystem_Sensor(t *testing.T) {
+	em := &entity.Manager{}
+	sensor := createCollider(em, 100, 100, &entity.CollisionComponent{
+		CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 50, H: 50}}, Sensor: true, IgnoreZ: true})
+	sensor.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent).Z = 1
+	obj := createCollider(em, 80, 110,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}})
+	physics := &entity.PhysicsComponent{Vel: &math.FPoint{X: 10}}
+	obj.AddComponent(physics)
+	position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+
+	var names []string
+	cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+	record := func(e Event) {
+		event := e.(*TriggerEvent)
+		if event.Sensor != sensor || event.Ent != obj {
+			t.Errorf("wrong event %v", event)
+		}
+		names = append(names, e.Name())
+	}
+	cs.AddHandler("trigger enter", record)
+	cs.AddHandler("trigger exit", record)
+	cs.AddHandler("collision event", InvertVel)
+
+	for _, x := range []int32{80, 95, 120, 200} {
+		position.Pos.X = x
+		cs.Update()
+	}
+
+	if want := []string{"trigger enter", "trigger exit"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("events == %v; want %v", names, want)
+	}
+	if physics.Vel.X != 10 {
+		t.Errorf("Vel.X == %f; want 10", physics.Vel.X)
+	}
+}
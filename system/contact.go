@@ -0,0 +1,82 @@
+package system
+
+import (
+	"github.com/tubelz/macaw/entity"
+)
+
+const (
+	// CollisionTick is the phase of the events notified every tick while the contact exists.
+	// They are named "collision event" and "border event"
+	CollisionTick = iota
+	// CollisionEnter is the phase of the events notified in the first tick of the contact
+	CollisionEnter
+	// CollisionStay is the phase of the events notified in the following ticks of the contact
+	CollisionStay
+	// CollisionExit is the phase of the events notified in the tick the contact ends
+	CollisionExit
+)
+
+// contactKey is a contact between two entities or, for borders, between an entity and a side of the world.
+// For triggers, ent is the sensor
+type contactKey struct {
+	ent     *entity.Entity
+	with    *entity.Entity
+	side    string
+	trigger bool
+}
+
+// contactTracker keeps the contacts of the previous tick, so we know when the contacts begin and end
+type contactTracker struct {
+	prev      map[contactKey]bool
+	prevOrder []contactKey
+	curr      map[contactKey]bool
+	currOrder []contactKey
+}
+
+// pairContact creates the contact between both entities. The entity with the lowest id is always the first
+func pairContact(obj1, obj2 *entity.Entity) contactKey {
+	if obj2.GetID() < obj1.GetID() {
+		obj1, obj2 = obj2, obj1
+	}
+	return contactKey{ent: obj1, with: obj2}
+}
+
+// triggerContact creates the contact of the entity with the sensor
+func triggerContact(sensor, obj *entity.Entity) contactKey {
+	return contactKey{ent: sensor, with: obj, trigger: true}
+}
+
+// touch adds the contact to the current tick and returns its phase (CollisionEnter or CollisionStay).
+// If the contact was already added in this tick, it returns false
+func (t *contactTracker) touch(k contactKey) (int, bool) {
+	if t.curr == nil {
+		t.curr = make(map[contactKey]bool)
+	}
+	if t.curr[k] {
+		return 0, false
+	}
+	t.curr[k] = true
+	t.currOrder = append(t.currOrder, k)
+	if t.prev[k] {
+		return CollisionStay, true
+	}
+	return CollisionEnter, true
+}
+
+// end finishes the current tick. It returns the contacts of the previous tick that ended, in the order they were
+// added
+func (t *contactTracker) end() []contactKey {
+	var ended []contactKey
+	for _, k := range t.prevOrder {
+		if !t.curr[k] {
+			ended = append(ended, k)
+		}
+	}
+	// we reuse the map and the slice of the previous tick in the next one
+	for k := range t.prev {
+		delete(t.prev, k)
+	}
+	t.prev, t.curr = t.curr, t.prev
+	t.prevOrder, t.currOrder = t.currOrder, t.prevOrder[:0]
+	return ended
+}
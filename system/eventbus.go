@@ -0,0 +1,114 @@
+package system
+
+import (
+	"reflect"
+)
+
+// DispatchMode defines when the events sent to an event bus are dispatched
+type DispatchMode int
+
+const (
+	// DispatchImmediate dispatches the events as soon as they are notified
+	DispatchImmediate DispatchMode = iota
+	// DispatchQueued queues the events until the event bus is flushed
+	DispatchQueued
+)
+
+// EventBus dispatches events to the handlers subscribed to their type, so systems and scenes can listen to each
+// other without holding references. The events can be dispatched immediately (Publish) or queued until
+// the next Flush (Queue). The game loop flushes the bus after running the update systems
+type EventBus struct {
+	handlers map[reflect.Type][]*listener
+	queue    []Event
+	lastID   uint64
+	recorder *EventRecorder
+}
+
+// Subscribe adds a handler for the events with the same type as the given event, e.g. &system.CollisionEvent{}.
+// It returns the subscription used to remove the handler
+func (b *EventBus) Subscribe(event Event, handler EventHandler) Subscription {
+	return b.SubscribeWithPriority(event, handler, 0)
+}
+
+// SubscribeWithPriority adds a handler with the given priority. The handlers with higher priority are called first.
+// See Subscribe
+func (b *EventBus) SubscribeWithPriority(event Event, handler EventHandler, priority int) Subscription {
+	if b.handlers == nil {
+		b.handlers = make(map[reflect.Type][]*listener)
+	}
+	eventType := reflect.TypeOf(event)
+	b.lastID++
+	l := &listener{id: b.lastID, handler: handler, priority: priority}
+	b.handlers[eventType] = insertListener(b.handlers[eventType], l)
+	return Subscription{eventType: eventType, id: b.lastID}
+}
+
+// Unsubscribe removes the handler of the subscription. If it's removed while an event is being dispatched,
+// it isn't called anymore
+func (b *EventBus) Unsubscribe(subscription Subscription) {
+	handlers := b.handlers[subscription.eventType]
+	for i, l := range handlers {
+		if l.id != subscription.id {
+			continue
+		}
+		l.removed = true
+		// the handlers are copied, since Publish may be iterating over them
+		rest := make([]*listener, 0, len(handlers)-1)
+		rest = append(append(rest, handlers[:i]...), handlers[i+1:]...)
+		b.handlers[subscription.eventType] = rest
+		return
+	}
+}
+
+// Publish dispatches the event to its handlers immediately. If a handler consumes the event (see Consumable),
+// the next handlers don't get it
+func (b *EventBus) Publish(event Event) {
+	var handlers []string
+	// the handlers may change the recorder, so the one that recorded the event gets its handlers
+	recorder := b.recorder
+	record := -1
+	if recorder != nil {
+		record = recorder.record(event, true)
+	}
+	for _, l := range b.handlers[reflect.TypeOf(event)] {
+		if l.removed {
+			continue
+		}
+		l.handler(event)
+		if recorder != nil {
+			handlers = append(handlers, handlerName(l.handler))
+		}
+		if isConsumed(event) {
+			break
+		}
+	}
+	if record >= 0 {
+		recorder.setHandlers(record, handlers)
+	}
+}
+
+// SetRecorder records the events published by the bus and the handlers called. A nil recorder stops it
+func (b *EventBus) SetRecorder(recorder *EventRecorder) {
+	b.recorder = recorder
+}
+
+// Queue queues the event to be dispatched in the next Flush
+func (b *EventBus) Queue(event Event) {
+	b.queue = append(b.queue, event)
+}
+
+// ClearQueue drops the queued events without dispatching them
+func (b *EventBus) ClearQueue() {
+	b.queue = nil
+}
+
+// Flush dispatches the queued events in the order they were queued.
+// Events queued by the handlers during the flush are dispatched in the next one, so handlers queuing events
+// again and again don't block the game loop
+func (b *EventBus) Flush() {
+	queue := b.queue
+	b.queue = nil
+	for _, event := range queue {
+		b.Publish(event)
+	}
+}
@@ -0,0 +1,102 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventBus_Subscribe(t *testing.T) {
+	var calls []string
+	bus := &EventBus{}
+	sub := bus.Subscribe(&BorderEvent{}, func(e Event) {
+		calls = append(calls, "border "+e.(*BorderEvent).Side)
+	})
+	bus.Subscribe(&testEvent{}, func(e Event) {
+		calls = append(calls, "test")
+	})
+
+	bus.Publish(&BorderEvent{Side: "top"})
+	bus.Publish(&testEvent{})
+	bus.Unsubscribe(sub)
+	bus.Publish(&BorderEvent{Side: "left"})
+
+	if want := []string{"border top", "test"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+}
+
+func TestEventBus_Queue(t *testing.T) {
+	var calls []string
+	bus := &EventBus{}
+	bus.Subscribe(&BorderEvent{}, func(e Event) {
+		side := e.(*BorderEvent).Side
+		calls = append(calls, side)
+		if side == "top" {
+			bus.Queue(&BorderEvent{Side: "queued by handler"})
+		}
+	})
+
+	bus.Queue(&BorderEvent{Side: "top"})
+	bus.Queue(&BorderEvent{Side: "left"})
+	if len(calls) != 0 {
+		t.Errorf("calls == %v before flushing; want none", calls)
+	}
+	bus.Flush()
+	if want := []string{"top", "left"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v after flushing; want %v", calls, want)
+	}
+	// the events queued during a flush wait for the next one
+	bus.Flush()
+	if want := []string{"top", "left", "queued by handler"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v after flushing again; want %v", calls, want)
+	}
+}
+
+func TestSubject_SetEventBus(t *testing.T) {
+	cases := []struct {
+		name          string
+		mode          DispatchMode
+		wantPublished bool
+	}{
+		{"immediate", DispatchImmediate, true},
+		{"queued", DispatchQueued, false},
+	}
+	for _, c := range cases {
+		bus := &EventBus{}
+		published := false
+		bus.Subscribe(&testEvent{}, func(e Event) {
+			published = true
+		})
+		s := &Subject{}
+		s.SetEventBus(bus, c.mode)
+		s.NotifyEvent(&testEvent{})
+		if published != c.wantPublished {
+			t.Errorf("%s: published == %v; want %v", c.name, published, c.wantPublished)
+		}
+		bus.Flush()
+		if !published {
+			t.Errorf("%s: event not published after flushing", c.name)
+		}
+	}
+}
+
+func TestEventBus_Priority(t *testing.T) {
+	var calls []string
+	bus := &EventBus{}
+	bus.Subscribe(&BorderEvent{}, func(e Event) {
+		calls = append(calls, "world")
+	})
+	bus.SubscribeWithPriority(&BorderEvent{}, func(e Event) {
+		calls = append(calls, "ui")
+		if e.(*BorderEvent).Side == "top" {
+			e.(*BorderEvent).Consume()
+		}
+	}, 1)
+
+	bus.Publish(&BorderEvent{Side: "top"})
+	bus.Publish(&BorderEvent{Side: "left"})
+
+	if want := []string{"ui", "ui", "world"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+}
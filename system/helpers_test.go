@@ -0,0 +1,14 @@
+package system
+
+import (
+	"github.com/tubelz/macaw/entity"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// createCollider creates an entity with the position and the collision component
+func createCollider(em *entity.Manager, x, y int32, collision *entity.CollisionComponent) *entity.Entity {
+	obj := em.Create("collider")
+	obj.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: x, Y: y}})
+	obj.AddComponent(collision)
+	return obj
+}
@@ -0,0 +1,240 @@
+package system
+
+import (
+	gomath "math"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+)
+
+const (
+	// defaultJointIterations is the number of iterations used to solve the joints if none is set
+	defaultJointIterations = 10
+	// jointBias is the fraction of the position error corrected each tick (Baumgarte stabilization)
+	jointBias = 0.2
+	// degToRad converts degrees to radians. Angles and angular velocities are in degrees
+	degToRad = gomath.Pi / 180
+)
+
+// JointBreakEvent has the joint entity that broke, the bodies it connected and the force that broke it
+type JointBreakEvent struct {
+	BaseEvent
+	Joint *entity.Entity
+	BodyA *entity.Entity
+	BodyB *entity.Entity
+	Force float32
+}
+
+// Name returns the joint break event name
+func (j *JointBreakEvent) Name() string {
+	return "joint break event"
+}
+
+// jointBody has the information of the body needed to solve a joint
+type jointBody struct {
+	physics *entity.PhysicsComponent
+	pos     math.FPoint // position of the body
+	r       math.FPoint // anchor relative to the body, rotated by its angle
+}
+
+// jointState is a joint being solved in the current tick
+type jointState struct {
+	obj     *entity.Entity
+	joint   *entity.JointComponent
+	a, b    jointBody
+	impulse math.FPoint // impulse accumulated during the tick
+}
+
+// solveJoints changes the velocities of the bodies so they respect their joints.
+// Springs apply forces, while the other joints are solved iteratively with impulses
+func (p *PhysicsSystem) solveJoints(dt float32) {
+	var joints []*jointState
+	requiredComponents := []entity.Component{&entity.JointComponent{}}
+	it := p.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		joint := obj.GetComponent(&entity.JointComponent{}).(*entity.JointComponent)
+		state, ok := newJointState(obj, joint)
+		if !ok {
+			continue
+		}
+		if joint.JointType == entity.JointSpring {
+			p.applySpring(state, dt)
+			continue
+		}
+		joints = append(joints, state)
+	}
+	if len(joints) == 0 {
+		return
+	}
+
+	iterations := p.Iterations
+	if iterations <= 0 {
+		iterations = defaultJointIterations
+	}
+	for i := 0; i < iterations; i++ {
+		for _, state := range joints {
+			solveJoint(state, dt)
+		}
+	}
+
+	// check if any joint was pulled too hard
+	for _, state := range joints {
+		force := length(state.impulse) / dt
+		p.checkBreak(state, force)
+	}
+}
+
+// newJointState gets the bodies of the joint. It returns false if the joint can't be solved
+func newJointState(obj *entity.Entity, joint *entity.JointComponent) (*jointState, bool) {
+	if joint.Broken || joint.BodyA == nil || joint.BodyB == nil {
+		return nil, false
+	}
+	a, ok := newJointBody(joint.BodyA, joint.AnchorA)
+	if !ok {
+		return nil, false
+	}
+	b, ok := newJointBody(joint.BodyB, joint.AnchorB)
+	if !ok {
+		return nil, false
+	}
+	if a.physics.InvMass == 0 && b.physics.InvMass == 0 {
+		return nil, false
+	}
+	return &jointState{obj: obj, joint: joint, a: a, b: b}, true
+}
+
+// newJointBody gets the position and the rotated anchor of the body
+func newJointBody(obj *entity.Entity, anchor math.FPoint) (jointBody, bool) {
+	component := obj.GetComponent(&entity.PhysicsComponent{})
+	if component == nil {
+		return jointBody{}, false
+	}
+	physics := component.(*entity.PhysicsComponent)
+	updateMass(physics)
+	if physics.Vel == nil {
+		physics.Vel = &math.FPoint{}
+	}
+	var pos math.FPoint
+	if physics.FuturePos != nil {
+		pos = *physics.FuturePos
+	} else if component = obj.GetComponent(&entity.PositionComponent{}); component != nil {
+		pos = *math.ConvertPointToFPoint(component.(*entity.PositionComponent).Pos)
+	} else {
+		return jointBody{}, false
+	}
+	sin, cos := gomath.Sincos(physics.FutureAngle * degToRad)
+	r := math.FPoint{
+		X: anchor.X*float32(cos) - anchor.Y*float32(sin),
+		Y: anchor.X*float32(sin) + anchor.Y*float32(cos),
+	}
+	return jointBody{physics: physics, pos: pos, r: r}, true
+}
+
+// anchor returns the position of the anchor in the world
+func (b *jointBody) anchor() math.FPoint {
+	return math.FPoint{X: b.pos.X + b.r.X, Y: b.pos.Y + b.r.Y}
+}
+
+// velocity returns the velocity of the anchor in the world
+func (b *jointBody) velocity() math.FPoint {
+	w := float32(b.physics.AngularVel * degToRad)
+	return math.FPoint{X: b.physics.Vel.X - w*b.r.Y, Y: b.physics.Vel.Y + w*b.r.X}
+}
+
+// invEffectiveMass returns the inverse of the mass the body opposes to an impulse along the normal
+func (b *jointBody) invEffectiveMass(normal math.FPoint) float32 {
+	rn := cross(b.r, normal)
+	return b.physics.InvMass + float32(b.physics.InvInertia*degToRad)*rn*rn
+}
+
+// applyImpulse changes the linear and angular velocity of the body
+func (b *jointBody) applyImpulse(impulse math.FPoint) {
+	b.physics.Vel.X += impulse.X * b.physics.InvMass
+	b.physics.Vel.Y += impulse.Y * b.physics.InvMass
+	b.physics.AngularVel += b.physics.InvInertia * float64(cross(b.r, impulse))
+}
+
+// solveJoint applies the impulse needed to satisfy the joint along each of its axis
+func solveJoint(state *jointState, dt float32) {
+	pa, pb := state.a.anchor(), state.b.anchor()
+	d := math.FPoint{X: pb.X - pa.X, Y: pb.Y - pa.Y}
+	switch state.joint.JointType {
+	case entity.JointPin:
+		// the anchors must be at the same point, so we solve each axis separately
+		solveAxis(state, math.FPoint{X: 1, Y: 0}, d.X, jointBias, dt, false)
+		solveAxis(state, math.FPoint{X: 0, Y: 1}, d.Y, jointBias, dt, false)
+	case entity.JointDistance, entity.JointRope:
+		dist := length(d)
+		if dist == 0 {
+			return
+		}
+		normal := math.FPoint{X: d.X / dist, Y: d.Y / dist}
+		if state.joint.JointType == entity.JointDistance {
+			solveAxis(state, normal, dist-state.joint.Length, jointBias, dt, false)
+		} else if dist < state.joint.Length {
+			// the rope is loose. It only pulls if the bodies would go beyond the length in this tick
+			solveAxis(state, normal, dist-state.joint.Length, 1, dt, true)
+		} else {
+			solveAxis(state, normal, dist-state.joint.Length, jointBias, dt, true)
+		}
+	}
+}
+
+// solveAxis applies the impulse to remove the relative velocity along the normal and to correct a fraction (bias)
+// of the position error. If onlyPull is set, the impulse can only pull the bodies together
+func solveAxis(state *jointState, normal math.FPoint, posError, bias, dt float32, onlyPull bool) {
+	k := state.a.invEffectiveMass(normal) + state.b.invEffectiveMass(normal)
+	if k == 0 {
+		return
+	}
+	va, vb := state.a.velocity(), state.b.velocity()
+	relVel := (vb.X-va.X)*normal.X + (vb.Y-va.Y)*normal.Y
+	lambda := -(relVel + bias*posError/dt) / k
+	if onlyPull && lambda > 0 {
+		return
+	}
+	impulse := math.FPoint{X: normal.X * lambda, Y: normal.Y * lambda}
+	state.a.applyImpulse(math.FPoint{X: -impulse.X, Y: -impulse.Y})
+	state.b.applyImpulse(impulse)
+	state.impulse.X += impulse.X
+	state.impulse.Y += impulse.Y
+}
+
+// applySpring applies the spring force to both bodies
+func (p *PhysicsSystem) applySpring(state *jointState, dt float32) {
+	pa, pb := state.a.anchor(), state.b.anchor()
+	d := math.FPoint{X: pb.X - pa.X, Y: pb.Y - pa.Y}
+	dist := length(d)
+	if dist == 0 {
+		return
+	}
+	normal := math.FPoint{X: d.X / dist, Y: d.Y / dist}
+	va, vb := state.a.velocity(), state.b.velocity()
+	relVel := (vb.X-va.X)*normal.X + (vb.Y-va.Y)*normal.Y
+	// Hooke's law with damping. A positive force pushes the bodies apart
+	force := -state.joint.Stiffness*(dist-state.joint.Length) - state.joint.Damping*relVel
+	impulse := math.FPoint{X: normal.X * force * dt, Y: normal.Y * force * dt}
+	state.a.applyImpulse(math.FPoint{X: -impulse.X, Y: -impulse.Y})
+	state.b.applyImpulse(impulse)
+	p.checkBreak(state, float32(gomath.Abs(float64(force))))
+}
+
+// checkBreak breaks the joint if the force is greater than its break force and notifies the observers
+func (p *PhysicsSystem) checkBreak(state *jointState, force float32) {
+	joint := state.joint
+	if joint.BreakForce <= 0 || force <= joint.BreakForce {
+		return
+	}
+	joint.Broken = true
+	p.NotifyEvent(&JointBreakEvent{Joint: state.obj, BodyA: joint.BodyA, BodyB: joint.BodyB, Force: force})
+}
+
+// cross returns the 2D cross product of two vectors
+func cross(a, b math.FPoint) float32 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+// length returns the length of the vector
+func length(a math.FPoint) float32 {
+	return float32(gomath.Sqrt(float64(a.X*a.X + a.Y*a.Y)))
+}
@@ -0,0 +1,72 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+)
+
+func TestPhysicsSystem_Joints(t *testing.T) {
+	cases := []struct {
+		name           string
+		joint          *entity.JointComponent
+		minLen, maxLen float32
+	}{
+		{"distance", &entity.JointComponent{JointType: entity.JointDistance, Length: 100}, 98, 102},
+		{"rope", &entity.JointComponent{JointType: entity.JointRope, Length: 150}, 0, 152},
+		{"pin", &entity.JointComponent{JointType: entity.JointPin, AnchorB: math.FPoint{X: -100, Y: 0}}, 98, 102},
+		{"spring", &entity.JointComponent{JointType: entity.JointSpring, Length: 100, Stiffness: 500, Damping: 5},
+			100, 200},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		p := &PhysicsSystem{EntityManager: em, Gravity: math.FPoint{X: 0, Y: 200}}
+		// a static body at the origin and a dynamic body at (100, 0)
+		anchor := em.Create("anchor")
+		anchor.AddComponent(&entity.PhysicsComponent{BodyType: entity.BodyStatic, FuturePos: &math.FPoint{X: 0, Y: 0}})
+		body := em.Create("body")
+		physicsB := &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 100, Y: 0}, FixedRotation: true}
+		body.AddComponent(physicsB)
+		c.joint.BodyA, c.joint.BodyB = anchor, body
+		em.Create("joint").AddComponent(c.joint)
+		for i := 0; i < 100; i++ {
+			p.Update()
+			dist := length(*physicsB.FuturePos)
+			if dist < c.minLen || dist > c.maxLen {
+				t.Errorf("%s: tick %d distance == %f; want between %f and %f", c.name, i, dist, c.minLen, c.maxLen)
+				break
+			}
+		}
+	}
+}
+
+func TestPhysicsSystem_JointBreak(t *testing.T) {
+	em := &entity.Manager{}
+	p := &PhysicsSystem{EntityManager: em, Gravity: math.FPoint{X: 0, Y: 200}}
+	anchor := em.Create("anchor")
+	anchor.AddComponent(&entity.PhysicsComponent{BodyType: entity.BodyStatic, FuturePos: &math.FPoint{X: 0, Y: 0}})
+	body := em.Create("body")
+	body.AddComponent(&entity.PhysicsComponent{FuturePos: &math.FPoint{X: 100, Y: 0}, FixedRotation: true})
+	joint := &entity.JointComponent{JointType: entity.JointDistance, Length: 50, BreakForce: 10, BodyA: anchor,
+		BodyB: body}
+	em.Create("joint").AddComponent(joint)
+	var event *JointBreakEvent
+	p.AddHandler("joint break event", func(e Event) {
+		event = e.(*JointBreakEvent)
+	})
+
+	p.Update()
+	if !joint.Broken {
+		t.Fatal("Joint should break")
+	}
+	if event == nil || event.BodyA != joint.BodyA || event.BodyB != joint.BodyB || event.Force <= 10 {
+		t.Errorf("Wrong joint break event: %v", event)
+	}
+	// broken joints are not solved anymore, thus no new events
+	event = nil
+	p.Update()
+	if event != nil {
+		t.Error("Broken joint notifying event again")
+	}
+}
@@ -0,0 +1,150 @@
+package system
+
+import (
+	gomath "math"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// maskView is the pixel mask of a collider placed in the world, with the flip of its sprite
+type maskView struct {
+	mask         *entity.PixelMask
+	x, y         int32
+	flipH, flipV bool
+}
+
+// newMaskView places the pixel mask of the collider in the world. It returns false if the collider has no mask
+func newMaskView(col collider) (maskView, bool) {
+	mask := col.collision.PixelMask
+	if mask == nil {
+		return maskView{}, false
+	}
+	view := maskView{mask: mask, x: col.position.Pos.X, y: col.position.Pos.Y}
+	if component := col.obj.GetComponent(&entity.RenderComponent{}); component != nil {
+		flip := component.(*entity.RenderComponent).Flip
+		view.flipH = flip&sdl.FLIP_HORIZONTAL != 0
+		view.flipV = flip&sdl.FLIP_VERTICAL != 0
+	}
+	return view, true
+}
+
+// bounds returns the rectangle covered by the mask in the world
+func (v maskView) bounds() sdl.Rect {
+	return sdl.Rect{X: v.x, Y: v.y, W: v.mask.W, H: v.mask.H}
+}
+
+// solid checks if the pixel of the world is solid in the mask
+func (v maskView) solid(x, y int32) bool {
+	x, y = x-v.x, y-v.y
+	if v.flipH {
+		x = v.mask.W - 1 - x
+	}
+	if v.flipV {
+		y = v.mask.H - 1 - y
+	}
+	return v.mask.Solid(x, y)
+}
+
+// masksOverlap checks if the colliders have solid pixels in the same place of the rectangle (where their collision
+// areas overlap). Colliders without pixel mask are solid in the whole rectangle. It returns the center of the
+// overlapping pixels. Only the part of the rectangle covered by the masks is scanned
+func masksOverlap(col1, col2 collider, rect sdl.Rect) (math.FPoint, bool) {
+	var views []maskView
+	for _, col := range []collider{col1, col2} {
+		if view, ok := newMaskView(col); ok {
+			bounds := view.bounds()
+			var intersects bool
+			if rect, intersects = rect.Intersect(&bounds); !intersects {
+				return math.FPoint{}, false
+			}
+			views = append(views, view)
+		}
+	}
+	var sum math.FPoint
+	count := 0
+	for y := rect.Y; y < rect.Y+rect.H; y++ {
+		for x := rect.X; x < rect.X+rect.W; x++ {
+			if solidInAll(views, x, y) {
+				sum.X += float32(x) + 0.5
+				sum.Y += float32(y) + 0.5
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return math.FPoint{}, false
+	}
+	return math.FPoint{X: sum.X / float32(count), Y: sum.Y / float32(count)}, true
+}
+
+// solidInAll checks if the pixel of the world is solid in all the masks
+func solidInAll(views []maskView, x, y int32) bool {
+	for _, view := range views {
+		if !view.solid(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+// overlaps checks if the shape overlaps a solid pixel of the mask inside the box of the collision area
+func (v maskView) overlaps(shape, area convexShape) bool {
+	rect := v.bounds()
+	for _, box := range []sdl.Rect{shape.box(), area.box()} {
+		var intersects bool
+		if rect, intersects = rect.Intersect(&box); !intersects {
+			return false
+		}
+	}
+	for y := rect.Y; y < rect.Y+rect.H; y++ {
+		for x := rect.X; x < rect.X+rect.W; x++ {
+			if v.solid(x, y) && shapesCollide(shape, rectShape(sdl.Rect{X: x, Y: y, W: 1, H: 1})) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// raycast returns where the ray hits the first solid pixel of the mask that is inside one of the collision areas,
+// the normal there and the area. The pixels crossed by the ray are walked like the tiles of a tile map
+func (v maskView) raycast(areas []convexShape, origin, direction math.FPoint,
+	maxDistance float32) (float32, math.FPoint, int, bool) {
+	bounds := v.bounds()
+	enter, exit, ok := raySlab(origin, direction, bounds)
+	if !ok || enter > maxDistance || bounds.W == 0 || bounds.H == 0 {
+		return 0, math.FPoint{}, 0, false
+	}
+	if exit > maxDistance {
+		exit = maxDistance
+	}
+	x := origin.X + direction.X*enter - float32(bounds.X)
+	y := origin.Y + direction.Y*enter - float32(bounds.Y)
+	col := clampInt(int(gomath.Floor(float64(x))), 0, int(bounds.W)-1)
+	row := clampInt(int(gomath.Floor(float64(y))), 0, int(bounds.H)-1)
+	stepCol, nextCol, deltaCol := ddaAxis(x, direction.X, 1, col, enter)
+	stepRow, nextRow, deltaRow := ddaAxis(y, direction.Y, 1, row, enter)
+	for t := enter; t <= exit; {
+		px, py := bounds.X+int32(col), bounds.Y+int32(row)
+		if v.solid(px, py) {
+			pixel := rectShape(sdl.Rect{X: px, Y: py, W: 1, H: 1})
+			if d, normal, ok := raycastShape(pixel, origin, direction); ok && d <= maxDistance {
+				// the center of the pixel must be in a collision area, like the pixels of the collisions
+				center := math.FPoint{X: float32(px) + 0.5, Y: float32(py) + 0.5}
+				for i, area := range areas {
+					if containsPoint(area, center) {
+						return d, normal, i, true
+					}
+				}
+			}
+		}
+		if nextCol < nextRow {
+			t, nextCol, col = nextCol, nextCol+deltaCol, col+stepCol
+		} else {
+			t, nextRow, row = nextRow, nextRow+deltaRow, row+stepRow
+		}
+	}
+	return 0, math.FPoint{}, 0, false
+}
@@ -0,0 +1,53 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCollisionSystem_PixelMask(t *testing.T) {
+	cases := []struct {
+		name      string
+		flip      sdl.RendererFlip
+		x2        int32
+		want      bool
+		wantPoint math.FPoint
+	}{
+		{"transparent pixels", sdl.FLIP_NONE, 5, false, math.FPoint{}},
+		{"flipped", sdl.FLIP_HORIZONTAL, 5, true, math.FPoint{X: 7.5, Y: 5}},
+		{"vertical flip", sdl.FLIP_VERTICAL, 5, false, math.FPoint{}},
+		{"solid pixels", sdl.FLIP_NONE, 2, true, math.FPoint{X: 2.5, Y: 5}},
+	}
+	// the pixel mask is solid only in the first three columns
+	mask := entity.NewPixelMask(10, 10)
+	for y := int32(0); y < 10; y++ {
+		for x := int32(0); x < 3; x++ {
+			mask.Set(x, y, true)
+		}
+	}
+	areas := []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		obj := createCollider(em, 0, 0, &entity.CollisionComponent{CollisionAreas: areas, PixelMask: mask})
+		obj.AddComponent(&entity.RenderComponent{Flip: c.flip})
+		createCollider(em, c.x2, 0, &entity.CollisionComponent{CollisionAreas: areas, PixelMask: mask})
+
+		var events []*CollisionEvent
+		cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+		cs.AddHandler("collision event", func(e Event) {
+			events = append(events, e.(*CollisionEvent))
+		})
+		cs.Update()
+
+		if got := len(events) > 0; got != c.want {
+			t.Errorf("%s: collision == %v; want %v", c.name, got, c.want)
+			continue
+		}
+		if c.want && (len(events[0].Points) != 1 || events[0].Points[0] != c.wantPoint) {
+			t.Errorf("%s: points == %v; want [%v]", c.name, events[0].Points, c.wantPoint)
+		}
+	}
+}
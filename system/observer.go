@@ -1,5 +1,9 @@
 package system
 
+import (
+	"reflect"
+)
+
 /*
  This is the implementation of the Observer Pattern so we can
  track events and execute functions we are interested on for
@@ -15,33 +19,184 @@ type Event interface {
 	Name() string
 }
 
+// Consumable is an event that can be consumed by a handler. The handlers with lower priority don't get
+// consumed events
+type Consumable interface {
+	Consume()
+	Consumed() bool
+}
+
+// BaseEvent can be embedded in the events to make them consumable
+type BaseEvent struct {
+	consumed bool
+}
+
+// Consume marks the event as consumed
+func (e *BaseEvent) Consume() {
+	e.consumed = true
+}
+
+// Consumed checks if the event was consumed
+func (e *BaseEvent) Consumed() bool {
+	return e.consumed
+}
+
+// isConsumed checks if the event is consumable and was consumed
+func isConsumed(event Event) bool {
+	consumable, ok := event.(Consumable)
+	return ok && consumable.Consumed()
+}
+
 // Subject holds the listerners and their handlers. An event can have multiple handlers.
+// The events can also be sent to an event bus (SetEventBus).
 type Subject struct {
-	listeners map[string][]EventHandler
+	listeners map[string][]*listener
+	lastID    uint64
+	bus       *EventBus
+	busMode   DispatchMode
+	recorder  *EventRecorder
+}
+
+// listener is an event handler added to the subject
+type listener struct {
+	id       uint64
+	handler  EventHandler
+	priority int
+	once     bool
+	removed  bool
 }
 
-// AddHandler adds an event handler
-func (s *Subject) AddHandler(eventName string, handler EventHandler) {
+// insertListener returns a copy of the listeners with the new one. The listeners are sorted by priority, the highest
+// first, and then by the order they were added. They are copied, since NotifyEvent may be iterating over them
+func insertListener(listeners []*listener, l *listener) []*listener {
+	i := len(listeners)
+	for i > 0 && listeners[i-1].priority < l.priority {
+		i--
+	}
+	inserted := make([]*listener, 0, len(listeners)+1)
+	inserted = append(append(inserted, listeners[:i]...), l)
+	return append(inserted, listeners[i:]...)
+}
+
+// Subscription identifies an event handler added to a subject or to an event bus.
+// It's used to remove the handler (RemHandler or EventBus.Unsubscribe)
+type Subscription struct {
+	eventName string
+	eventType reflect.Type
+	id        uint64
+}
+
+// AddHandler adds an event handler. It returns the subscription used to remove it
+func (s *Subject) AddHandler(eventName string, handler EventHandler) Subscription {
+	return s.addListener(eventName, handler, 0, false)
+}
+
+// AddHandlerWithPriority adds an event handler with the given priority. The handlers with higher priority are
+// called first. The ones added with AddHandler have priority 0
+func (s *Subject) AddHandlerWithPriority(eventName string, handler EventHandler, priority int) Subscription {
+	return s.addListener(eventName, handler, priority, false)
+}
+
+// AddOnceHandler adds an event handler that is removed after handling the first event
+func (s *Subject) AddOnceHandler(eventName string, handler EventHandler) Subscription {
+	return s.addListener(eventName, handler, 0, true)
+}
+
+// addListener adds the event handler to the listeners of the event
+func (s *Subject) addListener(eventName string, handler EventHandler, priority int, once bool) Subscription {
 	if s.listeners == nil {
-		s.listeners = make(map[string][]EventHandler)
+		s.listeners = make(map[string][]*listener)
 	}
-	s.listeners[eventName] = append(s.listeners[eventName], handler)
+	s.lastID++
+	l := &listener{id: s.lastID, handler: handler, priority: priority, once: once}
+	s.listeners[eventName] = insertListener(s.listeners[eventName], l)
+	return Subscription{eventName: eventName, id: s.lastID}
 }
 
-// RemHandler removes an event handler
-func (s *Subject) RemHandler() {
-	//TODO
+// RemHandler removes the event handler of the subscription. If it's removed while an event is being notified,
+// it isn't called anymore
+func (s *Subject) RemHandler(subscription Subscription) {
+	listeners := s.listeners[subscription.eventName]
+	for i, l := range listeners {
+		if l.id != subscription.id {
+			continue
+		}
+		l.removed = true
+		// the listeners are copied, since NotifyEvent may be iterating over them
+		rest := make([]*listener, 0, len(listeners)-1)
+		rest = append(append(rest, listeners[:i]...), listeners[i+1:]...)
+		s.listeners[subscription.eventName] = rest
+		return
+	}
 }
 
 // ClearEvents clear all events
 func (s *Subject) ClearEvents() {
+	for _, listeners := range s.listeners {
+		for _, l := range listeners {
+			l.removed = true
+		}
+	}
 	s.listeners = nil
 }
 
-// NotifyEvent executes all event handlers for a specific event
+// SetEventBus sends the events notified by the subject to the event bus, after its own handlers.
+// The mode defines if they are published (DispatchImmediate) or queued (DispatchQueued). A nil bus stops it
+func (s *Subject) SetEventBus(bus *EventBus, mode DispatchMode) {
+	s.bus = bus
+	s.busMode = mode
+}
+
+// SetRecorder records the events notified by the subject and the handlers called. A nil recorder stops it
+func (s *Subject) SetRecorder(recorder *EventRecorder) {
+	s.recorder = recorder
+}
+
+// NotifyEvent executes all event handlers for a specific event, and sends it to the event bus if it's set.
+// Handlers can be added and removed by the handlers. The ones added aren't called for the current event.
+// If a handler consumes the event (see Consumable), the next handlers and the event bus don't get it
 func (s *Subject) NotifyEvent(event Event) {
-	handlers := s.listeners[event.Name()]
-	for _, handler := range handlers {
-		handler(event)
+	eventName := event.Name()
+	listeners := s.listeners[eventName]
+	var handlers []string
+	// the handlers may change the recorder, so the one that recorded the event gets its handlers
+	recorder := s.recorder
+	record := -1
+	if recorder != nil {
+		record = recorder.record(event, false)
+	}
+	consumed := false
+	for _, l := range listeners {
+		if l.removed {
+			continue
+		}
+		if l.once {
+			s.RemHandler(Subscription{eventName: eventName, id: l.id})
+		}
+		l.handler(event)
+		if recorder != nil {
+			handlers = append(handlers, handlerName(l.handler))
+		}
+		if consumed = isConsumed(event); consumed {
+			break
+		}
+	}
+	if record >= 0 {
+		recorder.setHandlers(record, handlers)
+	}
+	if !consumed {
+		s.sendToBus(event)
+	}
+}
+
+// sendToBus sends the event to the event bus of the subject
+func (s *Subject) sendToBus(event Event) {
+	if s.bus == nil {
+		return
+	}
+	if s.busMode == DispatchQueued {
+		s.bus.Queue(event)
+	} else {
+		s.bus.Publish(event)
 	}
 }
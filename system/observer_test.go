@@ -0,0 +1,136 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+)
+
+// testEvent is an event used in the tests
+type testEvent struct{}
+
+func (e *testEvent) Name() string {
+	return "test event"
+}
+
+func TestSubject_RemHandler(t *testing.T) {
+	var calls []string
+	s := &Subject{}
+	first := s.AddHandler("test event", func(e Event) {
+		calls = append(calls, "first")
+	})
+	s.AddHandler("test event", func(e Event) {
+		calls = append(calls, "second")
+	})
+
+	s.NotifyEvent(&testEvent{})
+	s.RemHandler(first)
+	s.NotifyEvent(&testEvent{})
+	// removing twice does nothing
+	s.RemHandler(first)
+	s.RemHandler(Subscription{})
+
+	if want := []string{"first", "second", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+}
+
+func TestSubject_AddOnceHandler(t *testing.T) {
+	calls := 0
+	s := &Subject{}
+	s.AddOnceHandler("test event", func(e Event) {
+		calls++
+		// notifying from the handler doesn't call it again
+		s.NotifyEvent(e)
+	})
+
+	s.NotifyEvent(&testEvent{})
+	s.NotifyEvent(&testEvent{})
+
+	if calls != 1 {
+		t.Errorf("calls == %d; want 1", calls)
+	}
+}
+
+func TestSubject_ChangeWhileNotifying(t *testing.T) {
+	var calls []string
+	s := &Subject{}
+	var third Subscription
+	s.AddHandler("test event", func(e Event) {
+		calls = append(calls, "first")
+		s.RemHandler(third)
+		s.AddHandler("test event", func(e Event) {
+			calls = append(calls, "added")
+		})
+	})
+	var second Subscription
+	second = s.AddHandler("test event", func(e Event) {
+		calls = append(calls, "second")
+		s.RemHandler(second)
+	})
+	third = s.AddHandler("test event", func(e Event) {
+		calls = append(calls, "third")
+	})
+
+	s.NotifyEvent(&testEvent{})
+	if want := []string{"first", "second"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+
+	calls = nil
+	s.NotifyEvent(&testEvent{})
+	if want := []string{"first", "added"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+
+	calls = nil
+	s.ClearEvents()
+	s.NotifyEvent(&testEvent{})
+	if len(calls) != 0 {
+		t.Errorf("calls == %v; want none", calls)
+	}
+}
+
+// consumableEvent is a consumable event used in the tests
+type consumableEvent struct {
+	BaseEvent
+}
+
+func (e *consumableEvent) Name() string {
+	return "test event"
+}
+
+func TestSubject_Priority(t *testing.T) {
+	var calls []string
+	s := &Subject{}
+	add := func(name string, priority int, consume bool) {
+		s.AddHandlerWithPriority("test event", func(e Event) {
+			calls = append(calls, name)
+			if consumable, ok := e.(Consumable); ok && consume {
+				consumable.Consume()
+			}
+		}, priority)
+	}
+	add("default", 0, false)
+	add("ui", 10, true)
+	add("high", 20, false)
+	add("second default", 0, false)
+	bus := &EventBus{}
+	busHandler := func(e Event) {
+		calls = append(calls, "bus")
+	}
+	bus.Subscribe(&testEvent{}, busHandler)
+	bus.Subscribe(&consumableEvent{}, busHandler)
+	s.SetEventBus(bus, DispatchImmediate)
+
+	s.NotifyEvent(&testEvent{})
+	if want := []string{"high", "ui", "default", "second default", "bus"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+
+	// the handlers after the one consuming the event and the bus are skipped
+	calls = nil
+	s.NotifyEvent(&consumableEvent{})
+	if want := []string{"high", "ui"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls == %v; want %v", calls, want)
+	}
+}
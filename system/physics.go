@@ -1,34 +1,360 @@
 package system
 
 import (
+	gomath "math"
+
 	"github.com/tubelz/macaw/entity"
 	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
 )
 
 // PhysicsSystem is responsible to update the physics in the game.
 type PhysicsSystem struct {
 	EntityManager *entity.Manager
 	Name          string
+	// Gravity is the acceleration applied to every dynamic body, in units per second squared
+	Gravity math.FPoint
+	// Iterations is the number of iterations used to solve the joints. If it's not set, 10 iterations are used
+	Iterations int
 	Subject
 }
 
+// forceField has the force field component and its area in the world
+type forceField struct {
+	field *entity.ForceFieldComponent
+	area  sdl.Rect
+}
+
 // Init initializes this system. So far it does nothing.
 func (p *PhysicsSystem) Init() {}
 
-// Update change the position and velocity accordingly. We are using Semi-implicit Euler
+// Update change the position and velocity accordingly. We are using Semi-implicit Euler.
+// Velocity and acceleration are expressed in units per second, so they are scaled by the fixed timestep.
+// The physics owns the position of the bodies: the previous and current positions are kept in the physics
+// component and the position component is updated with the current one.
 func (p *PhysicsSystem) Update() {
 	var component interface{}
+	var bodies, characters []*entity.PhysicsComponent
 	phyComp := &entity.PhysicsComponent{}
+	dt := DeltaTime()
+	fields := p.forceFields()
 
+	// To use Semi-implicit Euler, we first update the velocity, then we update the position.
 	requiredComponents := []entity.Component{phyComp}
 	it := p.EntityManager.IterFilter(requiredComponents, -1)
 	for obj, i := it(); i != -1; obj, i = it() {
 		component = obj.GetComponent(phyComp)
 		physics := component.(*entity.PhysicsComponent)
+		initAngle(obj, physics)
+		if component = obj.GetComponent(&entity.PositionComponent{}); component != nil {
+			position := component.(*entity.PositionComponent)
+			if physics.FuturePos == nil {
+				physics.FuturePos = math.ConvertPointToFPoint(position.Pos)
+			}
+		}
+		// keep the state of the previous tick for the interpolation
+		if physics.FuturePos != nil {
+			physics.PrevPos = &math.FPoint{X: physics.FuturePos.X, Y: physics.FuturePos.Y}
+		}
+		physics.PrevAngle = physics.FutureAngle
+
+		updateMass(physics)
+		if physics.BodyType == entity.BodyStatic {
+			physics.Force = math.FPoint{}
+			physics.Torque = 0
+			continue
+		}
+		p.integrateVelocity(obj, physics, fields, dt)
+		// the position of the characters is updated by the character controller system
+		if obj.GetComponent(&entity.CharacterControllerComponent{}) != nil {
+			characters = append(characters, physics)
+		} else {
+			bodies = append(bodies, physics)
+		}
+	}
+
+	// the joints change the velocities so the bodies keep connected
+	p.solveJoints(dt)
+
+	for _, physics := range bodies {
+		physics.FuturePos = math.SumFPoint(physics.FuturePos, math.MulFPointWithFloat(physics.Vel, dt))
+	}
+	for _, physics := range append(bodies, characters...) {
+		if !physics.FixedRotation {
+			physics.FutureAngle += physics.AngularVel * float64(dt)
+		}
+	}
+
+	// the position component has the current position, so the other systems don't depend on the interpolation
+	requiredComponents = []entity.Component{phyComp, &entity.PositionComponent{}}
+	it = p.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		physics := obj.GetComponent(phyComp).(*entity.PhysicsComponent)
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		syncPosition(position, physics)
+	}
+}
+
+// syncPosition updates the position component with the simulated position
+func syncPosition(position *entity.PositionComponent, physics *entity.PhysicsComponent) {
+	if physics.FuturePos == nil {
+		return
+	}
+	if position.Pos == nil {
+		position.Pos = &sdl.Point{}
+	}
+	position.Pos.X = math.Round(physics.FuturePos.X)
+	position.Pos.Y = math.Round(physics.FuturePos.Y)
+}
+
+// integrateVelocity updates the linear and angular velocity of the body
+func (p *PhysicsSystem) integrateVelocity(obj *entity.Entity, physics *entity.PhysicsComponent,
+	fields []forceField, dt float32) {
+	acc := math.FPoint{}
+	if physics.Acc != nil {
+		acc = *physics.Acc
+	}
+	// only dynamic bodies are affected by gravity and forces
+	if physics.BodyType == entity.BodyDynamic {
+		applyForceFields(obj, physics, fields)
+		acc.X += p.Gravity.X + physics.Force.X*physics.InvMass
+		acc.Y += p.Gravity.Y + physics.Force.Y*physics.InvMass
+	}
+	physics.Force = math.FPoint{}
+	physics.Vel = math.SumFPoint(physics.Vel, math.MulFPointWithFloat(&acc, dt))
+	if physics.LinearDamping > 0 {
+		physics.Vel = math.MulFPointWithFloat(physics.Vel, 1/(1+dt*physics.LinearDamping))
+	}
+
+	if physics.FixedRotation {
+		physics.Torque = 0
+		return
+	}
+	angularAcc := physics.AngularAcc
+	if physics.BodyType == entity.BodyDynamic {
+		angularAcc += physics.Torque * physics.InvInertia
+	}
+	physics.Torque = 0
+	physics.AngularVel += angularAcc * float64(dt)
+	if physics.AngularDamping > 0 {
+		physics.AngularVel /= 1 + float64(dt)*physics.AngularDamping
+	}
+}
+
+// forceFields gets the force fields and their area in the world
+func (p *PhysicsSystem) forceFields() []forceField {
+	var fields []forceField
+	requiredComponents := []entity.Component{&entity.ForceFieldComponent{}, &entity.PositionComponent{}}
+	it := p.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		field := obj.GetComponent(&entity.ForceFieldComponent{}).(*entity.ForceFieldComponent)
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		area := field.Area
+		if position.Pos != nil {
+			area.X += position.Pos.X
+			area.Y += position.Pos.Y
+		}
+		fields = append(fields, forceField{field: field, area: area})
+	}
+	return fields
+}
+
+// applyForceFields adds to the body the forces of the fields overlapping it
+func applyForceFields(obj *entity.Entity, physics *entity.PhysicsComponent, fields []forceField) {
+	if len(fields) == 0 {
+		return
+	}
+	bounds, ok := bodyBounds(obj, physics)
+	if !ok {
+		return
+	}
+	for _, f := range fields {
+		if !f.area.HasIntersection(&bounds) {
+			continue
+		}
+		switch f.field.FieldType {
+		case entity.FieldDirectional:
+			physics.Force.X += f.field.Force.X
+			physics.Force.Y += f.field.Force.Y
+		case entity.FieldRadial:
+			// the direction goes from the center of the body to the center of the field
+			dx := float32(2*f.area.X+f.area.W-2*bounds.X-bounds.W) / 2
+			dy := float32(2*f.area.Y+f.area.H-2*bounds.Y-bounds.H) / 2
+			if dist := length(math.FPoint{X: dx, Y: dy}); dist > 0 {
+				physics.Force.X += dx / dist * f.field.Strength
+				physics.Force.Y += dy / dist * f.field.Strength
+			}
+		case entity.FieldDrag:
+			if physics.Vel != nil {
+				// the drag decelerates the body independently of its mass
+				physics.Force.X -= physics.Vel.X * f.field.Drag / physics.InvMass
+				physics.Force.Y -= physics.Vel.Y * f.field.Drag / physics.InvMass
+			}
+		}
+	}
+}
+
+// bodyBounds returns the rectangle that contains the body. If the body doesn't have collision areas,
+// its position is used
+func bodyBounds(obj *entity.Entity, physics *entity.PhysicsComponent) (sdl.Rect, bool) {
+	var x, y int32
+	if physics.FuturePos != nil {
+		x, y = math.Round(physics.FuturePos.X), math.Round(physics.FuturePos.Y)
+	} else if component := obj.GetComponent(&entity.PositionComponent{}); component != nil {
+		position := component.(*entity.PositionComponent)
+		if position.Pos == nil {
+			return sdl.Rect{}, false
+		}
+		x, y = position.Pos.X, position.Pos.Y
+	} else {
+		return sdl.Rect{}, false
+	}
+	bounds := sdl.Rect{X: x, Y: y, W: 1, H: 1}
+	if component := obj.GetComponent(&entity.CollisionComponent{}); component != nil {
+		collision := component.(*entity.CollisionComponent)
+		for i, area := range collision.CollisionAreas {
+			rect := sdl.Rect{X: x + area.X, Y: y + area.Y, W: area.W, H: area.H}
+			if i == 0 {
+				bounds = rect
+			} else {
+				bounds = bounds.Union(&rect)
+			}
+		}
+	}
+	return bounds, true
+}
+
+// initAngle starts the angle of the body with the angle of its sprite, the first time the body is updated
+func initAngle(obj *entity.Entity, physics *entity.PhysicsComponent) {
+	if physics.AngleInitialized {
+		return
+	}
+	physics.AngleInitialized = true
+	if component := obj.GetComponent(&entity.RenderComponent{}); component != nil {
+		physics.FutureAngle = component.(*entity.RenderComponent).Angle
+	}
+}
+
+// updateMass updates the inverse mass and inverse inertia of the body according to its type, mass and inertia
+func updateMass(physics *entity.PhysicsComponent) {
+	switch {
+	case physics.BodyType != entity.BodyDynamic:
+		physics.InvMass = 0
+	case physics.Mass > 0:
+		physics.InvMass = 1 / physics.Mass
+	default:
+		physics.InvMass = 1
+	}
+	switch {
+	case physics.BodyType != entity.BodyDynamic || physics.FixedRotation:
+		physics.InvInertia = 0
+	case physics.Inertia > 0:
+		physics.InvInertia = 1 / physics.Inertia
+	default:
+		physics.InvInertia = 1
+	}
+}
+
+/*
+	----
+	Util functions for handling physics events
+	----
+*/
+
+// ResolveCollision is a collision event handler that solves the collision between two rigid bodies using impulses.
+// It separates the penetrating bodies and changes their velocities according to their mass,
+// restitution and friction, conserving the momentum.
+// Entities without physics component are treated as static bodies.
+// Collisions with sensors are ignored.
+func ResolveCollision(event Event) {
+	collision := event.(*CollisionEvent)
+	// the collision system notifies the collision for both entities, so we solve the pair only once.
+	// Tile collisions are notified only for the collider
+	if collision.Tile == nil && collision.Ent.GetID() > collision.With.GetID() || isSensorCollision(collision) {
+		return
+	}
+	physics1 := rigidBody(collision.Ent)
+	physics2 := rigidBody(collision.With)
+	invMass := physics1.InvMass + physics2.InvMass
+	if invMass == 0 {
+		return
+	}
+	// the normal points from Ent to With. Continuous collisions don't penetrate
+	normal, depth := collision.Normal, collision.Depth
+	if normal == (math.FPoint{}) {
+		return
+	}
+	if depth > 0 {
+		// separate the bodies proportionally to their inverse mass
+		separate(collision.Ent, physics1, math.MulFPointWithFloat(&normal, -depth*physics1.InvMass/invMass))
+		separate(collision.With, physics2, math.MulFPointWithFloat(&normal, depth*physics2.InvMass/invMass))
+	}
 
-		// To use Semi-implicit Euler, we first update the velocity, then we update the position.
-		// FuturePos is used so we can interpolate with current position
-		physics.Vel = math.SumFPoint(physics.Vel, physics.Acc)
-		physics.FuturePos = math.SumFPoint(physics.FuturePos, physics.Vel)
+	// relative velocity along the normal. If it's positive the bodies are already moving apart
+	relVel := math.SumFPoint(physics2.Vel, math.MulFPointWithFloat(physics1.Vel, -1))
+	velAlongNormal := relVel.X*normal.X + relVel.Y*normal.Y
+	if velAlongNormal > 0 {
+		return
+	}
+	restitution := physics1.Restitution
+	if physics2.Restitution > restitution {
+		restitution = physics2.Restitution
+	}
+	j := -(1 + restitution) * velAlongNormal / invMass
+	applyImpulse(physics1, physics2, math.MulFPointWithFloat(&normal, j))
+
+	// friction works in the tangent direction of the contact
+	relVel = math.SumFPoint(physics2.Vel, math.MulFPointWithFloat(physics1.Vel, -1))
+	velAlongNormal = relVel.X*normal.X + relVel.Y*normal.Y
+	tangent := math.FPoint{X: relVel.X - normal.X*velAlongNormal, Y: relVel.Y - normal.Y*velAlongNormal}
+	tangentLength := length(tangent)
+	if tangentLength == 0 {
+		return
+	}
+	tangent.X /= tangentLength
+	tangent.Y /= tangentLength
+	jt := -(relVel.X*tangent.X + relVel.Y*tangent.Y) / invMass
+	// Coulomb's law: the friction impulse can't be greater than the normal impulse times the friction coefficient
+	mu := float32(gomath.Sqrt(float64(physics1.Friction * physics2.Friction)))
+	if maxFriction := j * mu; jt > maxFriction {
+		jt = maxFriction
+	} else if jt < -maxFriction {
+		jt = -maxFriction
+	}
+	applyImpulse(physics1, physics2, math.MulFPointWithFloat(&tangent, jt))
+}
+
+// rigidBody returns the physics component of the entity with its mass updated.
+// If the entity doesn't have one, it returns a static body
+func rigidBody(obj *entity.Entity) *entity.PhysicsComponent {
+	component := obj.GetComponent(&entity.PhysicsComponent{})
+	if component == nil {
+		return &entity.PhysicsComponent{BodyType: entity.BodyStatic, Vel: &math.FPoint{}}
+	}
+	physics := component.(*entity.PhysicsComponent)
+	updateMass(physics)
+	if physics.Vel == nil {
+		physics.Vel = &math.FPoint{}
+	}
+	return physics
+}
+
+// applyImpulse applies the impulse to the second body and the opposite impulse to the first one
+func applyImpulse(physics1, physics2 *entity.PhysicsComponent, impulse *math.FPoint) {
+	physics1.Vel = math.SumFPoint(physics1.Vel, math.MulFPointWithFloat(impulse, -physics1.InvMass))
+	physics2.Vel = math.SumFPoint(physics2.Vel, math.MulFPointWithFloat(impulse, physics2.InvMass))
+}
+
+// separate moves the body by the given displacement
+func separate(obj *entity.Entity, physics *entity.PhysicsComponent, displacement *math.FPoint) {
+	if physics.InvMass == 0 {
+		return
+	}
+	component := obj.GetComponent(&entity.PositionComponent{})
+	position := component.(*entity.PositionComponent)
+	if physics.FuturePos == nil {
+		physics.FuturePos = math.ConvertPointToFPoint(position.Pos)
 	}
+	physics.FuturePos = math.SumFPoint(physics.FuturePos, displacement)
+	syncPosition(position, physics)
 }
@@ -0,0 +1,218 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// simulate runs the physics system for one second using the given tick rate
+func simulate(ticks uint32, physics *entity.PhysicsComponent) {
+	SetTicksPerSecond(ticks)
+	defer SetTicksPerSecond(DefaultTicksPerSecond)
+	em := &entity.Manager{}
+	obj := em.Create("body")
+	obj.AddComponent(physics)
+	p := &PhysicsSystem{EntityManager: em}
+	for i := uint32(0); i < 1000/UpdateTickLength; i++ {
+		p.Update()
+	}
+}
+
+func TestPhysicsSystem_TickRateIndependent(t *testing.T) {
+	cases := []uint32{25, 50, 100}
+	for _, ticks := range cases {
+		physics := &entity.PhysicsComponent{
+			FuturePos: &math.FPoint{X: 0, Y: 0},
+			Vel:       &math.FPoint{X: 100, Y: 0},
+			Acc:       &math.FPoint{X: 0, Y: 10},
+		}
+		simulate(ticks, physics)
+		if diff := physics.FuturePos.X - 100; diff > 0.01 || diff < -0.01 {
+			t.Errorf("%d ticks: FuturePos.X == %f; want 100", ticks, physics.FuturePos.X)
+		}
+		if diff := physics.Vel.Y - 10; diff > 0.01 || diff < -0.01 {
+			t.Errorf("%d ticks: Vel.Y == %f; want 10", ticks, physics.Vel.Y)
+		}
+	}
+}
+
+func TestPhysicsSystem_PrevPos(t *testing.T) {
+	em := &entity.Manager{}
+	obj := em.Create("body")
+	position := &entity.PositionComponent{Pos: &sdl.Point{X: 10, Y: 0}}
+	physics := &entity.PhysicsComponent{Vel: &math.FPoint{X: 30, Y: 0}, AngularVel: 50}
+	obj.AddComponent(position)
+	obj.AddComponent(physics)
+	p := &PhysicsSystem{EntityManager: em}
+	p.Update()
+	p.Update()
+
+	// 0.6 pixels and 1 degree per tick
+	if diff := physics.FuturePos.X - physics.PrevPos.X - 0.6; diff > 0.001 || diff < -0.001 {
+		t.Errorf("PrevPos.X, FuturePos.X == %f, %f; want 0.6 apart", physics.PrevPos.X, physics.FuturePos.X)
+	}
+	if diff := physics.FutureAngle - physics.PrevAngle - 1; diff > 0.001 || diff < -0.001 {
+		t.Errorf("PrevAngle, FutureAngle == %f, %f; want 1 apart", physics.PrevAngle, physics.FutureAngle)
+	}
+	if position.Pos.X != 11 {
+		t.Errorf("Pos.X == %d; want 11", position.Pos.X)
+	}
+}
+
+func TestSetTicksPerSecond(t *testing.T) {
+	defer SetTicksPerSecond(DefaultTicksPerSecond)
+	cases := []struct {
+		in   uint32
+		want uint32
+	}{
+		{0, 20},
+		{50, 20},
+		{100, 10},
+		{5000, 1},
+	}
+	for _, c := range cases {
+		SetTicksPerSecond(c.in)
+		if UpdateTickLength != c.want {
+			t.Errorf("SetTicksPerSecond(%d): UpdateTickLength == %d; want %d", c.in, UpdateTickLength, c.want)
+		}
+	}
+}
+
+// newCollider returns the collider of the entity
+func newCollider(obj *entity.Entity) collider {
+	return collider{
+		obj:       obj,
+		position:  obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent),
+		collision: obj.GetComponent(&entity.CollisionComponent{}).(*entity.CollisionComponent),
+	}
+}
+
+func TestResolveCollision(t *testing.T) {
+	cases := []struct {
+		name               string
+		mass1, mass2       float32
+		vel1, vel2         float32
+		restitution        float32
+		bodyType2          int
+		wantVel1, wantVel2 float32
+		wantMove2          bool
+	}{
+		{"elastic equal mass", 1, 1, 100, -100, 1, entity.BodyDynamic, -100, 100, true},
+		{"inelastic equal mass", 1, 1, 100, -100, 0, entity.BodyDynamic, 0, 0, true},
+		{"elastic different mass", 1, 3, 100, 0, 1, entity.BodyDynamic, -50, 50, true},
+		{"static body", 1, 1, 100, 0, 1, entity.BodyStatic, -100, 0, false},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		physics1 := &entity.PhysicsComponent{Mass: c.mass1, Restitution: c.restitution, Vel: &math.FPoint{X: c.vel1},
+			FuturePos: &math.FPoint{X: 0, Y: 0}}
+		physics2 := &entity.PhysicsComponent{Mass: c.mass2, Restitution: c.restitution, Vel: &math.FPoint{X: c.vel2},
+			FuturePos: &math.FPoint{X: 6, Y: 0}, BodyType: c.bodyType2}
+		areas := []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}
+		obj1 := createCollider(em, 0, 0, &entity.CollisionComponent{CollisionAreas: areas})
+		obj1.AddComponent(physics1)
+		obj2 := createCollider(em, 6, 0, &entity.CollisionComponent{CollisionAreas: areas})
+		obj2.AddComponent(physics2)
+		momentum := c.mass1*c.vel1 + c.mass2*c.vel2
+
+		event, ok := collide(newCollider(obj1), newCollider(obj2))
+		if !ok {
+			t.Fatalf("%s: bodies aren't colliding", c.name)
+		}
+		ResolveCollision(event)
+		// the second notification of the pair must be ignored
+		ResolveCollision(event.reverse())
+
+		if physics1.Vel.X != c.wantVel1 || physics2.Vel.X != c.wantVel2 {
+			t.Errorf("%s: velocities == (%f, %f); want (%f, %f)", c.name, physics1.Vel.X, physics2.Vel.X,
+				c.wantVel1, c.wantVel2)
+		}
+		got := c.mass1*physics1.Vel.X + c.mass2*physics2.Vel.X
+		if c.bodyType2 == entity.BodyDynamic && got != momentum {
+			t.Errorf("%s: momentum == %f; want %f", c.name, got, momentum)
+		}
+		pos1 := obj1.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		pos2 := obj2.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		if pos1.Pos.X+10 > pos2.Pos.X {
+			t.Errorf("%s: bodies still penetrating. Positions: %d, %d", c.name, pos1.Pos.X, pos2.Pos.X)
+		}
+		if moved := pos2.Pos.X != 6; moved != c.wantMove2 {
+			t.Errorf("%s: second body moved == %t; want %t", c.name, moved, c.wantMove2)
+		}
+	}
+}
+
+func TestPhysicsSystem_ForceFields(t *testing.T) {
+	em := &entity.Manager{}
+	p := &PhysicsSystem{EntityManager: em, Gravity: math.FPoint{X: 0, Y: 50}}
+	field := em.Create("wind")
+	field.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: 0}})
+	field.AddComponent(&entity.ForceFieldComponent{Area: sdl.Rect{X: 0, Y: 0, W: 100, H: 100},
+		FieldType: entity.FieldDirectional, Force: math.FPoint{X: 100, Y: 0}})
+	inside := em.Create("body")
+	inside.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 10, Y: 0}})
+	inside.AddComponent(&entity.PhysicsComponent{Mass: 2, FuturePos: &math.FPoint{X: 10, Y: 0}})
+	outside := em.Create("body")
+	outside.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 200, Y: 0}})
+	outside.AddComponent(&entity.PhysicsComponent{Mass: 2, FuturePos: &math.FPoint{X: 200, Y: 0}})
+	static := em.Create("body")
+	static.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 20, Y: 0}})
+	static.AddComponent(&entity.PhysicsComponent{BodyType: entity.BodyStatic, FuturePos: &math.FPoint{X: 20, Y: 0}})
+
+	p.Update()
+
+	dt := DeltaTime()
+	physics := inside.GetComponent(&entity.PhysicsComponent{}).(*entity.PhysicsComponent)
+	if want := (&math.FPoint{X: 50 * dt, Y: 50 * dt}); *physics.Vel != *want {
+		t.Errorf("Body inside the field: Vel == %v; want %v", physics.Vel, want)
+	}
+	if physics.Force != (math.FPoint{}) {
+		t.Errorf("Force not cleared after update: %v", physics.Force)
+	}
+	physics = outside.GetComponent(&entity.PhysicsComponent{}).(*entity.PhysicsComponent)
+	if want := (&math.FPoint{X: 0, Y: 50 * dt}); *physics.Vel != *want {
+		t.Errorf("Body outside the field: Vel == %v; want %v", physics.Vel, want)
+	}
+	physics = static.GetComponent(&entity.PhysicsComponent{}).(*entity.PhysicsComponent)
+	if physics.Vel != nil {
+		t.Errorf("Static body should not move. Vel == %v", physics.Vel)
+	}
+}
+
+func TestPhysicsSystem_InitialAngle(t *testing.T) {
+	em := &entity.Manager{}
+	obj := em.Create("body")
+	physics := &entity.PhysicsComponent{FuturePos: &math.FPoint{X: 0, Y: 0}}
+	obj.AddComponent(&entity.RenderComponent{Angle: 30})
+	obj.AddComponent(physics)
+	p := &PhysicsSystem{EntityManager: em}
+	p.Update()
+	p.Update()
+
+	if physics.FutureAngle != 30 {
+		t.Errorf("FutureAngle == %f; want the angle of the sprite (30)", physics.FutureAngle)
+	}
+}
+
+func TestPhysicsSystem_AngularMotion(t *testing.T) {
+	cases := []struct {
+		name      string
+		physics   *entity.PhysicsComponent
+		wantAngle float64
+	}{
+		{"angular velocity", &entity.PhysicsComponent{AngularVel: 90}, 90},
+		{"torque", &entity.PhysicsComponent{Torque: 50, Inertia: 2}, 25 * 0.02},
+		{"fixed rotation", &entity.PhysicsComponent{AngularVel: 90, FixedRotation: true}, 0},
+		{"static", &entity.PhysicsComponent{AngularVel: 90, BodyType: entity.BodyStatic}, 0},
+	}
+	for _, c := range cases {
+		// torque is applied only in the first tick
+		simulate(50, c.physics)
+		if diff := c.physics.FutureAngle - c.wantAngle; diff > 0.001 || diff < -0.001 {
+			t.Errorf("%s: FutureAngle == %f; want %f", c.name, c.physics.FutureAngle, c.wantAngle)
+		}
+	}
+}
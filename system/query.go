@@ -0,0 +1,246 @@
+package system
+
+import (
+	gomath "math"
+	"sort"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// QueryFilter selects the colliders and tile maps found by the queries of the collision system.
+// The queries don't use the broadphase: every collider of the entity manager is checked on each query.
+// Like the collisions, the colliders with a pixel mask are only found on their solid pixels
+type QueryFilter struct {
+	// Mask has the collision layers of the colliders found. If it's 0, the colliders of all layers are found
+	Mask uint32
+	// Z is the Z plane of the colliders found, unless IgnoreZ is set
+	Z       float32
+	IgnoreZ bool
+	// IncludeSensors also finds the sensors
+	IncludeSensors bool
+}
+
+// RaycastHit is a collider hit by a ray. Point is where the ray hit it and Normal the normal of the surface there.
+// Area is the area hit, like in the collision events. Tile is set when the ray hit a tile map
+type RaycastHit struct {
+	Ent      *entity.Entity
+	Point    math.FPoint
+	Normal   math.FPoint
+	Distance float32
+	Area     int
+	Tile     *TileContact
+}
+
+// Raycast returns the first collider hit by the ray. The ray starts at the origin and goes in the direction until
+// the max distance, or forever if it's 0. Colliders containing the origin are hit at the origin.
+// Tile maps are hit at the first solid tile crossed by the ray
+func (c *CollisionSystem) Raycast(origin, direction math.FPoint, maxDistance float32,
+	filter QueryFilter) (RaycastHit, bool) {
+	hits := c.raycast(origin, direction, maxDistance, filter)
+	if len(hits) == 0 {
+		return RaycastHit{}, false
+	}
+	return hits[0], true
+}
+
+// RaycastAll returns all the colliders hit by the ray, from the closest to the farthest. See Raycast
+func (c *CollisionSystem) RaycastAll(origin, direction math.FPoint, maxDistance float32,
+	filter QueryFilter) []RaycastHit {
+	return c.raycast(origin, direction, maxDistance, filter)
+}
+
+// raycast returns the colliders hit by the ray, sorted by distance
+func (c *CollisionSystem) raycast(origin, direction math.FPoint, maxDistance float32,
+	filter QueryFilter) []RaycastHit {
+	l := math.Length(direction)
+	if l == 0 {
+		return nil
+	}
+	direction = math.FPoint{X: direction.X / l, Y: direction.Y / l}
+	if maxDistance <= 0 {
+		maxDistance = gomath.MaxFloat32
+	}
+	var hits []RaycastHit
+	c.query(filter, func(obj *entity.Entity, shapes []convexShape, mask *maskView) {
+		hit := RaycastHit{Ent: obj, Distance: maxDistance}
+		found := false
+		if mask != nil {
+			hit.Distance, hit.Normal, hit.Area, found = mask.raycast(shapes, origin, direction, maxDistance)
+		} else {
+			for i, shape := range shapes {
+				if t, normal, ok := raycastShape(shape, origin, direction); ok && t <= hit.Distance {
+					hit.Distance, hit.Normal, hit.Area = t, normal, i
+					found = true
+				}
+			}
+		}
+		if found {
+			hit.Point = math.FPoint{X: origin.X + direction.X*hit.Distance, Y: origin.Y + direction.Y*hit.Distance}
+			hits = append(hits, hit)
+		}
+	})
+	c.queryTiles(filter, func(m tileMap) {
+		if hit, ok := m.raycast(origin, direction, maxDistance); ok {
+			hits = append(hits, hit)
+		}
+	})
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Distance < hits[j].Distance
+	})
+	return hits
+}
+
+// OverlapRect returns the colliders overlapping the rectangle
+func (c *CollisionSystem) OverlapRect(rect sdl.Rect, filter QueryFilter) []*entity.Entity {
+	return c.overlap(rectShape(rect), filter)
+}
+
+// OverlapCircle returns the colliders overlapping the circle
+func (c *CollisionSystem) OverlapCircle(center math.FPoint, radius float32, filter QueryFilter) []*entity.Entity {
+	return c.overlap(convexShape{points: []math.FPoint{center}, radius: radius}, filter)
+}
+
+// PointQuery returns the colliders containing the point
+func (c *CollisionSystem) PointQuery(point math.FPoint, filter QueryFilter) []*entity.Entity {
+	var found []*entity.Entity
+	c.query(filter, func(obj *entity.Entity, shapes []convexShape, mask *maskView) {
+		if mask != nil && !mask.solid(int32(gomath.Floor(float64(point.X))), int32(gomath.Floor(float64(point.Y)))) {
+			return
+		}
+		for _, shape := range shapes {
+			if containsPoint(shape, point) {
+				found = append(found, obj)
+				return
+			}
+		}
+	})
+	c.queryTiles(filter, func(m tileMap) {
+		if m.containsPoint(point) {
+			found = append(found, m.obj)
+		}
+	})
+	return found
+}
+
+// overlap returns the colliders overlapping the shape
+func (c *CollisionSystem) overlap(query convexShape, filter QueryFilter) []*entity.Entity {
+	var found []*entity.Entity
+	c.query(filter, func(obj *entity.Entity, shapes []convexShape, mask *maskView) {
+		for _, shape := range shapes {
+			if shapesCollide(query, shape) && (mask == nil || mask.overlaps(query, shape)) {
+				found = append(found, obj)
+				return
+			}
+		}
+	})
+	c.queryTiles(filter, func(m tileMap) {
+		if m.overlaps(query) {
+			found = append(found, m.obj)
+		}
+	})
+	return found
+}
+
+// query calls the function with the collision areas and shapes of each collider selected by the filter, and its
+// pixel mask if it has one
+func (c *CollisionSystem) query(filter QueryFilter, f func(obj *entity.Entity, shapes []convexShape, mask *maskView)) {
+	requiredComponents := []entity.Component{&entity.PositionComponent{}, &entity.CollisionComponent{}}
+	it := c.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		collision := obj.GetComponent(&entity.CollisionComponent{}).(*entity.CollisionComponent)
+		if !filter.accepts(collision.Layer, position.Z) || collision.Sensor && !filter.IncludeSensors {
+			continue
+		}
+		var mask *maskView
+		if view, ok := newMaskView(collider{obj, position, collision}); ok {
+			mask = &view
+		}
+		f(obj, collisionShapes(obj, position, collision), mask)
+	}
+}
+
+// queryTiles calls the function with each tile map selected by the filter
+func (c *CollisionSystem) queryTiles(filter QueryFilter, f func(m tileMap)) {
+	requiredComponents := []entity.Component{&entity.PositionComponent{}, &entity.TileCollisionComponent{}}
+	it := c.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		tiles := obj.GetComponent(&entity.TileCollisionComponent{}).(*entity.TileCollisionComponent)
+		if filter.accepts(tiles.Layer, position.Z) {
+			f(tileMap{obj, position, tiles})
+		}
+	}
+}
+
+// accepts checks if the collision layer and the Z plane are selected by the filter
+func (f QueryFilter) accepts(layer uint32, z float32) bool {
+	mask := f.Mask
+	if mask == 0 {
+		mask = entity.AllCollisionLayers
+	}
+	if layer == 0 {
+		layer = entity.DefaultCollisionLayer
+	}
+	return layer&mask != 0 && (f.IgnoreZ || z == f.Z)
+}
+
+// containsPoint checks if the point is inside the shape
+func containsPoint(s convexShape, p math.FPoint) bool {
+	if len(s.points) == 0 {
+		return false
+	}
+	if s.radius > 0 {
+		return math.ConvexDistance([]math.FPoint{p}, s.points) < s.radius
+	}
+	return len(s.points) > 2 && math.PointInPolygon(p, s.points)
+}
+
+// raycastShape returns the distance from the origin to where the ray hits the shape, and the normal of the shape
+// there. The direction must be normalized
+func raycastShape(s convexShape, origin, direction math.FPoint) (float32, math.FPoint, bool) {
+	if containsPoint(s, origin) {
+		return 0, math.FPoint{X: -direction.X, Y: -direction.Y}, true
+	}
+	var normal math.FPoint
+	distance := float32(gomath.MaxFloat32)
+	hit := false
+	// the rounded shapes are their core grown by the radius: circles on the vertices and the edges moved outwards
+	if s.radius > 0 {
+		for _, p := range s.points {
+			if t, ok := math.RayCircle(origin, direction, p, s.radius); ok && t < distance {
+				point := math.FPoint{X: origin.X + direction.X*t, Y: origin.Y + direction.Y*t}
+				distance, hit = t, true
+				normal = math.FPoint{X: (point.X - p.X) / s.radius, Y: (point.Y - p.Y) / s.radius}
+			}
+		}
+	}
+	edges := len(s.points)
+	if edges == 2 {
+		edges = 1
+	} else if edges < 2 {
+		edges = 0
+	}
+	for i := 0; i < edges; i++ {
+		a, b := s.points[i], s.points[(i+1)%len(s.points)]
+		n := math.FPoint{X: a.Y - b.Y, Y: b.X - a.X}
+		l := math.Length(n)
+		if l == 0 {
+			continue
+		}
+		// the side of the edge facing the ray
+		n = math.FPoint{X: n.X / l, Y: n.Y / l}
+		if math.Dot(n, direction) > 0 {
+			n = math.FPoint{X: -n.X, Y: -n.Y}
+		}
+		offset := math.FPoint{X: n.X * s.radius, Y: n.Y * s.radius}
+		a = math.FPoint{X: a.X + offset.X, Y: a.Y + offset.Y}
+		b = math.FPoint{X: b.X + offset.X, Y: b.Y + offset.Y}
+		if t, ok := math.RaySegment(origin, direction, a, b); ok && t < distance {
+			distance, normal, hit = t, n, true
+		}
+	}
+	return distance, normal, hit
+}
@@ -0,0 +1,203 @@
+package system
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCollisionSystem_Raycast(t *testing.T) {
+	em := &entity.Manager{}
+	wall := createCollider(em, 100, -50,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 20, H: 100}}})
+	ball := createCollider(em, 200, 0,
+		&entity.CollisionComponent{Shapes: []entity.CollisionShape{{ShapeType: entity.ShapeCircle, Radius: 10}}})
+	sensor := createCollider(em, 50, -10, &entity.CollisionComponent{
+		CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}, Sensor: true})
+	back := createCollider(em, 150, -10,
+		&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 20}}})
+	back.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent).Z = 1
+	cs := &CollisionSystem{EntityManager: em}
+
+	origin, direction := math.FPoint{X: 0, Y: 0}, math.FPoint{X: 2, Y: 0}
+	hit, ok := cs.Raycast(origin, direction, 0, QueryFilter{})
+	want := RaycastHit{Ent: wall, Point: math.FPoint{X: 100, Y: 0}, Normal: math.FPoint{X: -1, Y: 0}, Distance: 100}
+	if !ok || hit != want {
+		t.Errorf("Raycast() == (%+v, %v); want (%+v, true)", hit, ok, want)
+	}
+	if _, ok := cs.Raycast(origin, direction, 50, QueryFilter{}); ok {
+		t.Errorf("Raycast() hit beyond the max distance")
+	}
+
+	cases := []struct {
+		name   string
+		filter QueryFilter
+		want   []*entity.Entity
+	}{
+		{"default", QueryFilter{}, []*entity.Entity{wall, ball}},
+		{"sensors", QueryFilter{IncludeSensors: true}, []*entity.Entity{sensor, wall, ball}},
+		{"z plane", QueryFilter{Z: 1}, []*entity.Entity{back}},
+		{"any z plane", QueryFilter{IgnoreZ: true}, []*entity.Entity{wall, back, ball}},
+		{"mask", QueryFilter{Mask: 1 << 5}, nil},
+	}
+	for _, c := range cases {
+		var got []*entity.Entity
+		for _, hit := range cs.RaycastAll(origin, direction, 0, c.filter) {
+			got = append(got, hit.Ent)
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: RaycastAll() found %d entities; want %d", c.name, len(got), len(c.want))
+		}
+	}
+
+	hits := cs.RaycastAll(origin, direction, 0, QueryFilter{})
+	if hits[1].Distance != 190 || hits[1].Normal != (math.FPoint{X: -1, Y: 0}) {
+		t.Errorf("RaycastAll() circle hit == %+v; want distance 190 and normal (-1, 0)", hits[1])
+	}
+}
+
+func TestCollisionSystem_Overlap(t *testing.T) {
+	em := &entity.Manager{}
+	box := createCollider(em, 0, 0, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}})
+	ball := createCollider(em, 30, 5,
+		&entity.CollisionComponent{Shapes: []entity.CollisionShape{{ShapeType: entity.ShapeCircle, Radius: 5}}})
+	cs := &CollisionSystem{EntityManager: em}
+
+	cases := []struct {
+		name string
+		got  []*entity.Entity
+		want []*entity.Entity
+	}{
+		{"rect", cs.OverlapRect(sdl.Rect{X: 5, Y: 0, W: 22, H: 5}, QueryFilter{}), []*entity.Entity{box, ball}},
+		{"touching rect", cs.OverlapRect(sdl.Rect{X: 10, Y: 0, W: 10, H: 10}, QueryFilter{}), nil},
+		{"circle", cs.OverlapCircle(math.FPoint{X: 20, Y: 5}, 6, QueryFilter{}), []*entity.Entity{ball}},
+		{"point", cs.PointQuery(math.FPoint{X: 5, Y: 5}, QueryFilter{}), []*entity.Entity{box}},
+		{"point in circle", cs.PointQuery(math.FPoint{X: 33, Y: 3}, QueryFilter{}), []*entity.Entity{ball}},
+		{"point outside", cs.PointQuery(math.FPoint{X: 20, Y: 5}, QueryFilter{}), nil},
+	}
+	for _, c := range cases {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: found %d entities; want %d", c.name, len(c.got), len(c.want))
+		}
+	}
+}
+
+func TestCollisionSystem_QueryTiles(t *testing.T) {
+	em := &entity.Manager{}
+	level := em.Create("level")
+	level.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: 0}})
+	level.AddComponent(&entity.TileCollisionComponent{TileSize: sdl.Point{X: 10, Y: 10}, Tiles: [][]int{
+		{entity.TileEmpty, entity.TileEmpty, entity.TileEmpty, entity.TileEmpty},
+		{entity.TileFull, entity.TileEmpty, entity.TileSlopeUp, entity.TileOneWay},
+	}})
+	cs := &CollisionSystem{EntityManager: em}
+
+	cases := []struct {
+		name              string
+		origin, direction math.FPoint
+		maxDistance       float32
+		want              *RaycastHit
+	}{
+		{"wall", math.FPoint{X: -20, Y: 15}, math.FPoint{X: 1, Y: 0}, 0, &RaycastHit{Ent: level,
+			Point: math.FPoint{X: 0, Y: 15}, Normal: math.FPoint{X: -1, Y: 0}, Distance: 20,
+			Tile: &TileContact{Col: 0, Row: 1, Type: entity.TileFull}}},
+		{"slope", math.FPoint{X: 25, Y: -10}, math.FPoint{X: 0, Y: 1}, 0, &RaycastHit{Ent: level,
+			Point: math.FPoint{X: 25, Y: 15}, Normal: math.FPoint{X: -0.7071, Y: -0.7071}, Distance: 25,
+			Tile: &TileContact{Col: 2, Row: 1, Type: entity.TileSlopeUp}}},
+		{"one way from above", math.FPoint{X: 35, Y: -10}, math.FPoint{X: 0, Y: 1}, 0, &RaycastHit{Ent: level,
+			Point: math.FPoint{X: 35, Y: 10}, Normal: math.FPoint{X: 0, Y: -1}, Distance: 20,
+			Tile: &TileContact{Col: 3, Row: 1, Type: entity.TileOneWay}}},
+		{"one way from below", math.FPoint{X: 35, Y: 30}, math.FPoint{X: 0, Y: -1}, 0, nil},
+		{"empty row", math.FPoint{X: -5, Y: 5}, math.FPoint{X: 1, Y: 0}, 0, nil},
+		{"max distance", math.FPoint{X: -20, Y: 15}, math.FPoint{X: 1, Y: 0}, 10, nil},
+	}
+	for _, c := range cases {
+		hit, ok := cs.Raycast(c.origin, c.direction, c.maxDistance, QueryFilter{})
+		if ok != (c.want != nil) {
+			t.Errorf("%s: Raycast() hit == %v; want %v", c.name, ok, c.want != nil)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		diff := math.Length(math.FPoint{X: hit.Normal.X - c.want.Normal.X, Y: hit.Normal.Y - c.want.Normal.Y})
+		if hit.Ent != c.want.Ent || hit.Point != c.want.Point || hit.Distance != c.want.Distance || diff > 0.001 ||
+			hit.Tile == nil || *hit.Tile != *c.want.Tile {
+			t.Errorf("%s: Raycast() == %+v; want %+v", c.name, hit, *c.want)
+		}
+	}
+
+	overlaps := []struct {
+		name string
+		got  []*entity.Entity
+		want []*entity.Entity
+	}{
+		{"rect on wall", cs.OverlapRect(sdl.Rect{X: 2, Y: 12, W: 3, H: 3}, QueryFilter{}), []*entity.Entity{level}},
+		{"rect on empty tile", cs.OverlapRect(sdl.Rect{X: 12, Y: 12, W: 3, H: 3}, QueryFilter{}), nil},
+		{"point in slope", cs.PointQuery(math.FPoint{X: 28, Y: 18}, QueryFilter{}), []*entity.Entity{level}},
+		{"point above slope", cs.PointQuery(math.FPoint{X: 22, Y: 12}, QueryFilter{}), nil},
+		{"mask", cs.OverlapRect(sdl.Rect{X: 2, Y: 12, W: 3, H: 3}, QueryFilter{Mask: 1 << 5}), nil},
+	}
+	for _, c := range overlaps {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: found %d entities; want %d", c.name, len(c.got), len(c.want))
+		}
+	}
+}
+
+func TestCollisionSystem_QueryPixelMask(t *testing.T) {
+	// the pixel mask is solid only in the first three columns
+	mask := entity.NewPixelMask(10, 10)
+	for y := int32(0); y < 10; y++ {
+		for x := int32(0); x < 3; x++ {
+			mask.Set(x, y, true)
+		}
+	}
+	areas := []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}
+	em := &entity.Manager{}
+	obj := createCollider(em, 0, 0, &entity.CollisionComponent{CollisionAreas: areas, PixelMask: mask})
+	flipped := createCollider(em, 100, 0, &entity.CollisionComponent{CollisionAreas: areas, PixelMask: mask})
+	flipped.AddComponent(&entity.RenderComponent{Flip: sdl.FLIP_HORIZONTAL})
+	cs := &CollisionSystem{EntityManager: em}
+
+	cases := []struct {
+		name string
+		got  []*entity.Entity
+		want []*entity.Entity
+	}{
+		{"solid point", cs.PointQuery(math.FPoint{X: 1, Y: 5}, QueryFilter{}), []*entity.Entity{obj}},
+		{"transparent point", cs.PointQuery(math.FPoint{X: 5, Y: 5}, QueryFilter{}), nil},
+		{"flipped point", cs.PointQuery(math.FPoint{X: 108, Y: 5}, QueryFilter{}), []*entity.Entity{flipped}},
+		{"solid rect", cs.OverlapRect(sdl.Rect{X: 2, Y: 2, W: 3, H: 3}, QueryFilter{}), []*entity.Entity{obj}},
+		{"transparent rect", cs.OverlapRect(sdl.Rect{X: 5, Y: 0, W: 3, H: 3}, QueryFilter{}), nil},
+	}
+	for _, c := range cases {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: found %d entities; want %d", c.name, len(c.got), len(c.want))
+		}
+	}
+
+	rays := []struct {
+		name      string
+		origin    math.FPoint
+		direction math.FPoint
+		want      RaycastHit
+		wantOk    bool
+	}{
+		{"solid pixels", math.FPoint{X: 20, Y: 5}, math.FPoint{X: -1, Y: 0},
+			RaycastHit{Ent: obj, Point: math.FPoint{X: 3, Y: 5}, Normal: math.FPoint{X: 1, Y: 0}, Distance: 17}, true},
+		{"flipped", math.FPoint{X: 90, Y: 5}, math.FPoint{X: 1, Y: 0},
+			RaycastHit{Ent: flipped, Point: math.FPoint{X: 107, Y: 5}, Normal: math.FPoint{X: -1, Y: 0}, Distance: 17},
+			true},
+		{"transparent pixels", math.FPoint{X: 5, Y: -10}, math.FPoint{X: 0, Y: 1}, RaycastHit{}, false},
+	}
+	for _, c := range rays {
+		hit, ok := cs.Raycast(c.origin, c.direction, 50, QueryFilter{})
+		if ok != c.wantOk || hit != c.want {
+			t.Errorf("%s: Raycast() == (%+v, %v); want (%+v, %v)", c.name, hit, ok, c.want, c.wantOk)
+		}
+	}
+}
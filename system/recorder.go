@@ -0,0 +1,163 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/tubelz/macaw/entity"
+)
+
+const (
+	// DefaultRecorderTicks is the number of ticks kept by the event recorders without MaxTicks
+	DefaultRecorderTicks = 300
+	// maxPayloadLength is the maximum length of the payload summary of the records
+	maxPayloadLength = 160
+)
+
+// EventRecord is an event dispatched by a subject or by an event bus (Bus), in the given tick.
+// Handlers has the names of the functions that handled it
+type EventRecord struct {
+	Tick     uint64
+	Name     string
+	Payload  string
+	Handlers []string
+	Bus      bool
+}
+
+// EventRecorder records the events dispatched in the last ticks, to debug the chains of event handlers.
+// Use SetRecorder in the subjects (systems) and the event bus to record their events. The game loop creates one
+// in debug mode (-debug)
+type EventRecorder struct {
+	// MaxTicks is the number of ticks kept. If it's 0, DefaultRecorderTicks is used
+	MaxTicks int
+	tick     uint64
+	records  []EventRecord
+}
+
+// NextTick starts a new tick and forgets the records older than the ticks kept. The game loop calls it before
+// updating the systems
+func (r *EventRecorder) NextTick() {
+	r.tick++
+	maxTicks := uint64(r.MaxTicks)
+	if maxTicks == 0 {
+		maxTicks = DefaultRecorderTicks
+	}
+	if r.tick <= maxTicks {
+		return
+	}
+	first := r.tick - maxTicks + 1
+	i := 0
+	for i < len(r.records) && r.records[i].Tick < first {
+		i++
+	}
+	r.records = append(r.records[:0], r.records[i:]...)
+}
+
+// Tick returns the current tick
+func (r *EventRecorder) Tick() uint64 {
+	return r.tick
+}
+
+// Records returns a copy of the records of the last ticks, from the oldest to the newest
+func (r *EventRecorder) Records() []EventRecord {
+	records := make([]EventRecord, len(r.records))
+	copy(records, r.records)
+	return records
+}
+
+// Dump writes the records of the last ticks, one per line. If ticks is 0, all the records kept are written
+func (r *EventRecorder) Dump(w io.Writer, ticks int) error {
+	var first uint64
+	if ticks > 0 && uint64(ticks) <= r.tick {
+		first = r.tick - uint64(ticks) + 1
+	}
+	for _, record := range r.records {
+		if record.Tick < first {
+			continue
+		}
+		source := "subject"
+		if record.Bus {
+			source = "bus"
+		}
+		_, err := fmt.Fprintf(w, "tick %d [%s] %s %s handlers: [%s]\n", record.Tick, source, record.Name,
+			record.Payload, strings.Join(record.Handlers, ", "))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpFile writes the records of the last ticks to the file. See Dump
+func (r *EventRecorder) DumpFile(path string, ticks int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err = r.Dump(w, ticks); err == nil {
+		err = w.Flush()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// record adds the event to the current tick before it's dispatched, so the events notified by its handlers come
+// after it. It returns the position of the record, used to set the handlers once they are called
+func (r *EventRecorder) record(event Event, bus bool) int {
+	r.records = append(r.records, EventRecord{Tick: r.tick, Name: event.Name(), Payload: payload(event), Bus: bus})
+	return len(r.records) - 1
+}
+
+// setHandlers sets the handlers called for the event of the record
+func (r *EventRecorder) setHandlers(record int, handlers []string) {
+	r.records[record].Handlers = handlers
+}
+
+// entityType is the type of the entities in the events, which are summarized by their id
+var entityType = reflect.TypeOf(&entity.Entity{})
+
+// payload returns a summary of the data of the event
+func payload(event Event) string {
+	value := reflect.ValueOf(event)
+	if value.Kind() == reflect.Ptr && !value.IsNil() {
+		value = value.Elem()
+	}
+	var summary string
+	if value.Kind() == reflect.Struct {
+		fields := make([]string, value.NumField())
+		for i := range fields {
+			fields[i] = value.Type().Field(i).Name + ":" + fieldSummary(value.Field(i))
+		}
+		summary = "{" + strings.Join(fields, " ") + "}"
+	} else {
+		summary = fmt.Sprintf("%+v", value)
+	}
+	if len(summary) > maxPayloadLength {
+		summary = summary[:maxPayloadLength-3] + "..."
+	}
+	return summary
+}
+
+// fieldSummary returns the value of the field of the event. Entities are written as their ids
+func fieldSummary(field reflect.Value) string {
+	if field.Type() == entityType && !field.IsNil() && field.CanInterface() {
+		return fmt.Sprintf("entity %d", field.Interface().(*entity.Entity).GetID())
+	}
+	return fmt.Sprintf("%+v", field)
+}
+
+// handlerName returns the name of the function of the event handler
+func handlerName(handler EventHandler) string {
+	if f := runtime.FuncForPC(reflect.ValueOf(handler).Pointer()); f != nil {
+		return f.Name()
+	}
+	return "unknown"
+}
@@ -0,0 +1,100 @@
+package system
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+)
+
+// recordedHandler is an event handler used to check the names recorded
+func recordedHandler(e Event) {}
+
+func TestEventRecorder(t *testing.T) {
+	recorder := &EventRecorder{MaxTicks: 2}
+	s := &Subject{}
+	s.SetRecorder(recorder)
+	s.AddHandler("border event", recordedHandler)
+	bus := &EventBus{}
+	bus.SetRecorder(recorder)
+	s.SetEventBus(bus, DispatchImmediate)
+
+	for _, side := range []string{"top", "left", "right"} {
+		recorder.NextTick()
+		s.NotifyEvent(&BorderEvent{Side: side})
+	}
+
+	// the first tick was forgotten. Each event is recorded by the subject and the bus
+	records := recorder.Records()
+	if len(records) != 4 {
+		t.Fatalf("%d records; want 4", len(records))
+	}
+	first := records[0]
+	if first.Tick != 2 || first.Name != "border event" || first.Bus || !strings.Contains(first.Payload, "Side:left") {
+		t.Errorf("record == %+v; want border event of the second tick with side left", first)
+	}
+	if len(first.Handlers) != 1 || !strings.HasSuffix(first.Handlers[0], "system.recordedHandler") {
+		t.Errorf("handlers == %v; want [system.recordedHandler]", first.Handlers)
+	}
+	if !records[1].Bus || len(records[1].Handlers) != 0 {
+		t.Errorf("record == %+v; want recorded by the bus without handlers", records[1])
+	}
+
+	var buf bytes.Buffer
+	if err := recorder.Dump(&buf, 1); err != nil {
+		t.Fatalf("Dump() returned %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "tick 3 [subject] border event") ||
+		!strings.HasPrefix(lines[1], "tick 3 [bus] border event") {
+		t.Errorf("Dump() ==\n%s\nwant the events of the third tick", buf.String())
+	}
+}
+
+func TestEventRecorder_NestedEvents(t *testing.T) {
+	recorder := &EventRecorder{}
+	s := &Subject{}
+	s.SetRecorder(recorder)
+	s.AddHandler("border event", func(e Event) {
+		s.NotifyEvent(&testEvent{})
+	})
+	recorder.NextTick()
+	s.NotifyEvent(&BorderEvent{Side: "top"})
+
+	// the event comes before the events notified by its handlers
+	records := recorder.Records()
+	if len(records) != 2 || records[0].Name != "border event" || records[1].Name != (&testEvent{}).Name() {
+		t.Fatalf("records == %+v; want the border event and then the event notified by its handler", records)
+	}
+	if len(records[0].Handlers) != 1 {
+		t.Errorf("handlers == %v; want the handler of the border event", records[0].Handlers)
+	}
+}
+
+func TestEventRecorder_ChangedRecorder(t *testing.T) {
+	recorder := &EventRecorder{}
+	s := &Subject{}
+	s.SetRecorder(recorder)
+	s.AddHandler("border event", func(e Event) {
+		s.SetRecorder(nil)
+	})
+	em := &entity.Manager{}
+	em.Create("first")
+	obj := em.Create("border")
+	recorder.NextTick()
+	s.NotifyEvent(&BorderEvent{Ent: obj, Side: "top"})
+
+	// the recorder removed by the handler still gets the handlers of the event
+	records := recorder.Records()
+	if len(records) != 1 || len(records[0].Handlers) != 1 {
+		t.Fatalf("records == %+v; want the border event with its handler", records)
+	}
+	if want := "Ent:entity 1"; !strings.Contains(records[0].Payload, want) {
+		t.Errorf("payload == %q; want it to contain %q", records[0].Payload, want)
+	}
+	records[0].Name = "changed"
+	if recorder.Records()[0].Name != "border event" {
+		t.Error("changing the records returned changed the recorder")
+	}
+}
@@ -1,11 +1,22 @@
 package system
 
 import (
+	"errors"
+	"sort"
+
 	"github.com/tubelz/macaw/entity"
 	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+const (
+	// SortByLayer draws the entities ordered by render layer and then by Z
+	SortByLayer = iota
+	// SortByY also orders the entities in the same layer and Z by their bottom (Y), for top-down games
+	SortByY
+)
+
 // RenderSystem is probably one of the most important system. It is responsible to render (draw) the entities
 type RenderSystem struct {
 	EntityManager *entity.Manager
@@ -16,12 +27,34 @@ type RenderSystem struct {
 	accumulator   uint32          // used for interpolation
 	time          uint32          // used for animation
 	Name          string
+	// SortMode is how the entities are ordered to be drawn (SortByLayer or SortByY)
+	SortMode int
+	// RendererFlags are the flags used to create the renderer. If it's 0, sdl.RENDERER_ACCELERATED is used.
+	// Use sdl.RENDERER_SOFTWARE to run without a graphics card
+	RendererFlags uint32
+	items         []renderItem      // entities of the previous frame, in the order of the entity manager
+	sorted        []renderItem      // entities of the previous frame, in the order they are drawn
+	scratch       []renderItem      // reused to collect the entities of each frame
+	views         []entity.Entitier // reused to collect the cameras of each frame
+}
+
+// renderItem is an entity to be drawn and its sorting keys. Order is its position in the entity manager
+type renderItem struct {
+	obj   *entity.Entity
+	layer int
+	z     float32
+	y     int32
+	order int
 }
 
 // Init initializes the render system using the current window
 func (r *RenderSystem) Init() {
 	var err error
-	if r.Renderer, err = sdl.CreateRenderer(r.Window, -1, sdl.RENDERER_ACCELERATED); err != nil {
+	flags := r.RendererFlags
+	if flags == 0 {
+		flags = sdl.RENDERER_ACCELERATED
+	}
+	if r.Renderer, err = sdl.CreateRenderer(r.Window, -1, flags); err != nil {
 		logFatalf("Renderer could not be created! SDL Error: %s\n", sdl.GetError())
 	} else {
 		//Initialize renderer color
@@ -40,82 +73,209 @@ func (r *RenderSystem) UpdateTime(time uint32) {
 	r.time = time
 }
 
-// SetCamera sets the camera which controls what will be rendered
+// SetCamera sets the main camera, which controls what will be rendered. The other active cameras of the entity
+// manager are also drawn
 func (r *RenderSystem) SetCamera(camera entity.Entitier) {
 	r.Camera = camera
 }
 
 // GetCameraPosition gets the camera position
 func (r *RenderSystem) GetCameraPosition() (int32, int32) {
-	if component := r.Camera.GetComponent(&entity.PositionComponent{}); component != nil {
+	return cameraPosition(r.Camera)
+}
+
+// OffsetPosition changes the cartesian position according to the camera
+func (r *RenderSystem) OffsetPosition(x, y int32) (int32, int32) {
+	return offsetPosition(r.Camera, x, y)
+}
+
+// cameraPosition gets the position of the camera
+func cameraPosition(camera entity.Entitier) (int32, int32) {
+	if component := camera.GetComponent(&entity.PositionComponent{}); component != nil {
 		position := component.(*entity.PositionComponent)
 		return position.Pos.X, position.Pos.Y
 	}
 	return 0, 0
 }
 
-// OffsetPosition changes the cartesian position according to the camera
-func (r *RenderSystem) OffsetPosition(x, y int32) (int32, int32) {
-	camX, camY := r.GetCameraPosition()
+// offsetPosition changes the cartesian position according to the given camera
+func offsetPosition(camera entity.Entitier, x, y int32) (int32, int32) {
+	camX, camY := cameraPosition(camera)
 	x -= camX
 	y -= camY
 	return x, y
 }
 
-func (r *RenderSystem) isRenderable(pos *sdl.Point, size sdl.Rect) bool {
-	if r.Camera == nil {
+// isRenderable checks if the object is seen by the camera
+func isRenderable(camera entity.Entitier, pos *sdl.Point, size sdl.Rect) bool {
+	if camera == nil {
 		return false
 	}
-	if component := r.Camera.GetComponent(&entity.PositionComponent{}); component != nil {
+	if component := camera.GetComponent(&entity.PositionComponent{}); component != nil {
 		position := component.(*entity.PositionComponent)
-		c := r.Camera.GetComponent(&entity.CameraComponent{})
-		camera := c.(*entity.CameraComponent)
+		c := camera.GetComponent(&entity.CameraComponent{})
+		cam := c.(*entity.CameraComponent)
 
 		// check if there is an intersection
 		objRect := &sdl.Rect{X: pos.X, Y: pos.Y, W: size.W, H: size.H}
-		cameraRect := sdl.Rect{X: position.Pos.X, Y: position.Pos.Y, W: camera.ViewportSize.X, H: camera.ViewportSize.Y}
+		view := cam.ViewSize()
+		cameraRect := sdl.Rect{X: position.Pos.X, Y: position.Pos.Y, W: view.X, H: view.Y}
 		return cameraRect.HasIntersection(objRect)
 	}
 	return false
 }
 
 // Update will draw the entities accordingly to their position.
-// it can render animated sprites, fonts or geometry
+// it can render animated sprites, fonts or geometry. Each camera is drawn in its viewport, ordered by Order
 func (r *RenderSystem) Update() {
-	var component entity.Component
-
-	if r.Camera == nil {
+	cameras := r.cameras()
+	if len(cameras) == 0 {
 		logFatal("Please, assign at least one camera to the render system")
 	}
 
 	r.Renderer.SetDrawColor(r.BgColor.R, r.BgColor.G, r.BgColor.B, r.BgColor.A)
 	r.Renderer.Clear()
+	r.drawCameras(cameras)
+	r.Renderer.Present()
+}
+
+// drawCameras draws the entities seen by each camera in its viewport of the current render target
+func (r *RenderSystem) drawCameras(cameras []entity.Entitier) {
+	for _, camera := range cameras {
+		r.Renderer.SetViewport(cameraComponent(camera).Viewport)
+		r.draw(camera, nil)
+	}
+	r.Renderer.SetViewport(nil)
+}
+
+// cameras returns the main camera and the active cameras of the entity manager, in the order they are drawn
+func (r *RenderSystem) cameras() []entity.Entitier {
+	cameras := r.views[:0]
+	if r.Camera != nil {
+		cameras = append(cameras, r.Camera)
+	}
+	if r.EntityManager != nil {
+		requiredComponents := []entity.Component{&entity.CameraComponent{}, &entity.PositionComponent{}}
+		it := r.EntityManager.IterFilter(requiredComponents, -1)
+		for obj, i := it(); i != -1; obj, i = it() {
+			camera := obj.GetComponent(&entity.CameraComponent{}).(*entity.CameraComponent)
+			if camera.IsActive && r.Camera != obj {
+				cameras = append(cameras, obj)
+			}
+		}
+	}
+	sort.SliceStable(cameras, func(i, j int) bool {
+		return cameraComponent(cameras[i]).Order < cameraComponent(cameras[j]).Order
+	})
+	r.views = cameras
+	return cameras
+}
+
+// CreateRenderTarget creates a texture the render system can draw into (RenderToTexture)
+func (r *RenderSystem) CreateRenderTarget(w, h int32) (*sdl.Texture, error) {
+	texture, err := r.Renderer.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA8888), sdl.TEXTUREACCESS_TARGET, w, h)
+	if err != nil {
+		return nil, err
+	}
+	texture.SetBlendMode(sdl.BLENDMODE_BLEND)
+	return texture, nil
+}
+
+// RenderToTexture draws the entities seen by the camera into the texture, which must be a render target
+// (CreateRenderTarget). It can be used for minimaps, transitions or effects. If the camera is nil, the camera of
+// the system is used. The camera fills the texture, its viewport is ignored. If the filter is set, only the
+// entities accepted by it are drawn
+func (r *RenderSystem) RenderToTexture(target *sdl.Texture, camera entity.Entitier,
+	filter func(obj *entity.Entity) bool) error {
+	if camera == nil {
+		camera = r.Camera
+	}
+	if camera == nil {
+		return errors.New("the render system has no camera")
+	}
+	previous := r.Renderer.GetRenderTarget()
+	if err := r.Renderer.SetRenderTarget(target); err != nil {
+		return err
+	}
+	defer r.Renderer.SetRenderTarget(previous)
+
+	r.Renderer.SetDrawColor(r.BgColor.R, r.BgColor.G, r.BgColor.B, r.BgColor.A)
+	r.Renderer.Clear()
+	r.draw(camera, filter)
+	return nil
+}
+
+// Screenshot draws the current frame, with all the cameras in their viewports, and saves it as a PNG image in
+// the path
+func (r *RenderSystem) Screenshot(path string) error {
+	cameras := r.cameras()
+	if len(cameras) == 0 {
+		return errors.New("the render system has no camera")
+	}
+	w, h, err := r.Renderer.GetOutputSize()
+	if err != nil {
+		return err
+	}
+	target, err := r.CreateRenderTarget(w, h)
+	if err != nil {
+		return err
+	}
+	defer target.Destroy()
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, w, h, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+	if err != nil {
+		return err
+	}
+	defer surface.Free()
+
+	previous := r.Renderer.GetRenderTarget()
+	if err = r.Renderer.SetRenderTarget(target); err != nil {
+		return err
+	}
+	r.Renderer.SetDrawColor(r.BgColor.R, r.BgColor.G, r.BgColor.B, r.BgColor.A)
+	r.Renderer.Clear()
+	r.drawCameras(cameras)
+	err = r.Renderer.ReadPixels(nil, uint32(sdl.PIXELFORMAT_ARGB8888), surface.Data(), int(surface.Pitch))
+	r.Renderer.SetRenderTarget(previous)
+	if err != nil {
+		return err
+	}
+	return img.SavePNG(surface, path)
+}
+
+// draw draws the entities seen by the camera and accepted by the filter, or all of them if it's nil, in the
+// current render target
+func (r *RenderSystem) draw(camera entity.Entitier, filter func(obj *entity.Entity) bool) {
+	var component entity.Component
 
 	// interpolation variable
-	alpha := float32(r.accumulator) / UpdateTickLength
+	alpha := float32(r.accumulator) / float32(UpdateTickLength)
 
-	requiredComponents := []entity.Component{&entity.RenderComponent{}, &entity.PositionComponent{}}
-	it := r.EntityManager.IterFilter(requiredComponents, -1)
-	for obj, i := it(); i != -1; obj, i = it() {
+	for _, item := range r.drawOrder() {
+		obj := item.obj
+		if filter != nil && !filter(obj) || !cameraComponent(camera).SeesLayer(item.layer) {
+			continue
+		}
 		// Position component
 		component = obj.GetComponent(&entity.PositionComponent{})
 		position := component.(*entity.PositionComponent)
-		// Do interpolation if necessary - requires physics component (physics)
+		// Render component
+		component = obj.GetComponent(&entity.RenderComponent{})
+		render := component.(*entity.RenderComponent)
+
+		// Do interpolation if necessary - requires physics component (physics).
+		// The components are not changed, so the simulation doesn't depend on the render
+		pos := position.Pos
+		angle := render.Angle
 		if component = obj.GetComponent(&entity.PhysicsComponent{}); component != nil {
 			physics := component.(*entity.PhysicsComponent)
-			if physics.FuturePos == nil {
-				position.Pos.X = 0
-				position.Pos.Y = 0
-			} else {
-				pos1 := &sdl.Point{X: int32(physics.FuturePos.X), Y: int32(physics.FuturePos.Y)}
-				position.Pos = lerp(position.Pos, pos1, alpha)
+			if physics.FuturePos != nil {
+				pos = lerp(physics.PrevPos, physics.FuturePos, alpha)
+			}
+			if !physics.FixedRotation {
+				angle = lerpAngle(physics.PrevAngle, physics.FutureAngle, alpha)
 			}
 		}
 
-		// Render component
-		component = obj.GetComponent(&entity.RenderComponent{})
-		render := component.(*entity.RenderComponent)
-
 		switch render.RenderType {
 		case entity.RTSprite:
 			// Check for animation component
@@ -136,7 +296,7 @@ func (r *RenderSystem) Update() {
 			}
 		case entity.RTGeometry:
 			// Check for geometry components
-			r.drawGeometry(obj, position.Pos)
+			r.drawGeometry(camera, obj, pos)
 			continue
 		case entity.RTGrid:
 			// Grid component
@@ -151,17 +311,104 @@ func (r *RenderSystem) Update() {
 		// Offset according to the camera
 		crop := *render.Crop
 		var x, y int32
-		if !r.isRenderable(position.Pos, crop) {
+		if !isRenderable(camera, pos, crop) {
 			// check if it is necessary to render
 			continue
 		} else {
-			x, y = r.OffsetPosition(position.Pos.X, position.Pos.Y)
+			x, y = offsetPosition(camera, pos.X, pos.Y)
 		}
 
 		dst := createDestPos(*position, *render, x, y)
-		r.Renderer.CopyEx(render.Texture, &crop, dst, render.Angle, render.Center, render.Flip)
+		r.Renderer.CopyEx(render.Texture, &crop, dst, angle, render.Center, render.Flip)
 	}
-	r.Renderer.Present()
+}
+
+// drawOrder returns the entities to be drawn, sorted by layer, Z and, with SortByY, their bottom.
+// The entities are sorted only if they changed since the previous frame. If only their keys changed, like
+// the entities moving with SortByY, the previous order is almost sorted and is fixed with an insertion sort
+func (r *RenderSystem) drawOrder() []renderItem {
+	items := r.scratch[:0]
+	requiredComponents := []entity.Component{&entity.RenderComponent{}, &entity.PositionComponent{}}
+	it := r.EntityManager.IterFilter(requiredComponents, -1)
+	for obj, i := it(); i != -1; obj, i = it() {
+		position := obj.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent)
+		render := obj.GetComponent(&entity.RenderComponent{}).(*entity.RenderComponent)
+		item := renderItem{obj: obj, layer: render.Layer, z: position.Z, order: len(items)}
+		if r.SortMode == SortByY {
+			item.y = position.Pos.Y
+			if render.Crop != nil {
+				item.y += render.Crop.H
+			}
+		}
+		items = append(items, item)
+	}
+	if sameItems(items, r.items) {
+		r.scratch = items
+		return r.sorted
+	}
+	if sameEntities(items, r.items) {
+		for i, item := range r.sorted {
+			r.sorted[i] = items[item.order]
+		}
+		insertionSort(r.sorted)
+	} else {
+		r.sorted = append(r.sorted[:0], items...)
+		sort.Slice(r.sorted, func(i, j int) bool {
+			return drawsBefore(r.sorted[i], r.sorted[j])
+		})
+	}
+	r.items, r.scratch = items, r.items
+	return r.sorted
+}
+
+// drawsBefore checks if the entity a is drawn before b. The entities with the same keys keep the order of the
+// entity manager
+func drawsBefore(a, b renderItem) bool {
+	if a.layer != b.layer {
+		return a.layer < b.layer
+	}
+	if a.z != b.z {
+		return a.z > b.z
+	}
+	if a.y != b.y {
+		return a.y < b.y
+	}
+	return a.order < b.order
+}
+
+// insertionSort sorts the entities that are almost sorted
+func insertionSort(items []renderItem) {
+	for i := 1; i < len(items); i++ {
+		for j := i; j > 0 && drawsBefore(items[j], items[j-1]); j-- {
+			items[j], items[j-1] = items[j-1], items[j]
+		}
+	}
+}
+
+// sameItems checks if the entities and their sorting keys are the same
+func sameItems(a, b []renderItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameEntities checks if the entities are the same, even if their sorting keys changed
+func sameEntities(a, b []renderItem) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].obj != b[i].obj {
+			return false
+		}
+	}
+	return true
 }
 
 // createDestPos creates the rect destination using the Z position, thus with perspective.
@@ -208,7 +455,7 @@ func (r *RenderSystem) generateTextureFromFont(render *entity.RenderComponent, f
 }
 
 // drawGeometry draws on the renderer the geometry. We don't use texture, because it's faster to draw directly using the renderer
-func (r *RenderSystem) drawGeometry(geometryEntity *entity.Entity, pos *sdl.Point) {
+func (r *RenderSystem) drawGeometry(camera entity.Entitier, geometryEntity *entity.Entity, pos *sdl.Point) {
 	render := r.Renderer
 	var component entity.Component
 	component = geometryEntity.GetComponent(&entity.RectangleComponent{})
@@ -218,11 +465,11 @@ func (r *RenderSystem) drawGeometry(geometryEntity *entity.Entity, pos *sdl.Poin
 		w := g.Size.X
 		h := g.Size.Y
 		// Offset position according to camera
-		x, y := r.OffsetPosition(pos.X, pos.Y)
+		x, y := offsetPosition(camera, pos.X, pos.Y)
 		// Result of rectangle to draw
 		rect := &sdl.Rect{X: x, Y: y, W: w, H: h}
 		// check if it is necessary to render
-		if !r.isRenderable(pos, *rect) {
+		if !isRenderable(camera, pos, *rect) {
 			return
 		}
 		if g.Filled {
@@ -236,13 +483,22 @@ func (r *RenderSystem) drawGeometry(geometryEntity *entity.Entity, pos *sdl.Poin
 }
 
 // lerp is the linear interpolation. pos0 is the old position, pos1 is the new position,
-// alpha is the coeficient of the linear interpolation
-func lerp(pos0, pos1 *sdl.Point, alpha float32) *sdl.Point {
-	x := math.Round(float32(pos1.X)*alpha + float32(pos0.X)*(1.0-alpha))
-	y := math.Round(float32(pos1.Y)*alpha + float32(pos0.Y)*(1.0-alpha))
+// alpha is the coeficient of the linear interpolation. If there is no old position, the new one is used
+func lerp(pos0, pos1 *math.FPoint, alpha float32) *sdl.Point {
+	if pos0 == nil {
+		pos0 = pos1
+	}
+	x := math.Round(pos1.X*alpha + pos0.X*(1.0-alpha))
+	y := math.Round(pos1.Y*alpha + pos0.Y*(1.0-alpha))
 	return &sdl.Point{X: x, Y: y}
 }
 
+// lerpAngle is the linear interpolation of angles. angle0 is the old angle, angle1 is the new angle,
+// alpha is the coeficient of the linear interpolation
+func lerpAngle(angle0, angle1 float64, alpha float32) float64 {
+	return angle1*float64(alpha) + angle0*(1.0-float64(alpha))
+}
+
 // nextAnimation returns the crop for the next animation
 func nextAnimation(now uint32, anim *entity.AnimationComponent, currentRect *sdl.Rect) *sdl.Rect {
 	dt := now - anim.PreviousTime
@@ -265,11 +521,11 @@ func nextAnimation(now uint32, anim *entity.AnimationComponent, currentRect *sdl
 	return &sdl.Rect{X: x, Y: y, W: currentRect.W, H: currentRect.H}
 }
 
-// drawGrid is used to draw a grid to help debugging
+// drawGrid is used to draw a grid to help debugging. It fills the current viewport
 func (r *RenderSystem) drawGrid(grid *entity.GridComponent) {
 	render := r.Renderer
-	var area sdl.Point
-	area.X, area.Y = r.Window.GetSize()
+	viewport := render.GetViewport()
+	area := sdl.Point{X: viewport.W, Y: viewport.H}
 
 	if grid.Color != nil {
 		render.SetDrawColor(grid.Color.R, grid.Color.G, grid.Color.B, grid.Color.A)
@@ -286,3 +542,11 @@ func (r *RenderSystem) drawGrid(grid *entity.GridComponent) {
 		}
 	}
 }
+
+// cameraComponent returns the camera component of the camera, or an empty one if it doesn't have it
+func cameraComponent(camera entity.Entitier) *entity.CameraComponent {
+	if component := camera.GetComponent(&entity.CameraComponent{}); component != nil {
+		return component.(*entity.CameraComponent)
+	}
+	return &entity.CameraComponent{}
+}
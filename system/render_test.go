@@ -0,0 +1,248 @@
+package system
+
+import (
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestRenderSystem_DrawOrder(t *testing.T) {
+	em := &entity.Manager{}
+	createSprite := func(y int32, z float32, layer int) *entity.Entity {
+		obj := em.Create("sprite")
+		obj.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: y}, Z: z})
+		obj.AddComponent(&entity.RenderComponent{Crop: &sdl.Rect{X: 0, Y: 0, W: 10, H: 10}, Layer: layer})
+		return obj
+	}
+	player := createSprite(50, 0, 1)
+	background := createSprite(0, 0, 0)
+	tree := createSprite(40, 0, 1)
+	mountains := createSprite(0, 2, 1)
+	rs := &RenderSystem{EntityManager: em}
+
+	check := func(name string, want ...*entity.Entity) {
+		order := rs.drawOrder()
+		if len(order) != len(want) {
+			t.Fatalf("%s: %d entities drawn; want %d", name, len(order), len(want))
+		}
+		for i, item := range order {
+			if item.obj != want[i] {
+				t.Errorf("%s: entity %d drawn in position %d; want %d", name, item.obj.GetID(), i, want[i].GetID())
+			}
+		}
+	}
+
+	check("layers", background, mountains, player, tree)
+	// nothing changed, so the previous order is reused without sorting. Swapping it shows if it was sorted again
+	rs.sorted[0], rs.sorted[1] = rs.sorted[1], rs.sorted[0]
+	check("same frame", mountains, background, player, tree)
+	rs.sorted[0], rs.sorted[1] = rs.sorted[1], rs.sorted[0]
+
+	rs.SortMode = SortByY
+	check("y sort", background, mountains, tree, player)
+
+	// the player walks behind the tree
+	player.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent).Pos.Y = 20
+	check("player moved", background, mountains, player, tree)
+	player.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent).Pos.Y = 50
+	check("player moved back", background, mountains, tree, player)
+	// the same bottom keeps the order of the entity manager
+	tree.GetComponent(&entity.PositionComponent{}).(*entity.PositionComponent).Pos.Y = 50
+	check("same bottom", background, mountains, player, tree)
+
+	// a new entity reusing the slot of the background is still drawn behind
+	em.Delete(background.GetID())
+	background = createSprite(0, 0, 0)
+	hud := createSprite(0, 0, 2)
+	check("slot reused", background, mountains, player, tree, hud)
+}
+
+func TestRenderSystem_RenderToTextureWithoutCamera(t *testing.T) {
+	rs := &RenderSystem{EntityManager: &entity.Manager{}}
+	if err := rs.RenderToTexture(nil, nil, nil); err == nil {
+		t.Error("rendering without a camera should fail")
+	}
+}
+
+func TestRenderSystem_Cameras(t *testing.T) {
+	em := &entity.Manager{}
+	createCamera := func(order int, active bool) *entity.Entity {
+		camera := em.Create("camera")
+		camera.AddComponent(&entity.PositionComponent{})
+		camera.AddComponent(&entity.CameraComponent{IsActive: active, Order: order})
+		return camera
+	}
+	hud := createCamera(1, true)
+	player1 := createCamera(0, false)
+	createCamera(0, false)
+	player2 := createCamera(0, true)
+	rs := &RenderSystem{EntityManager: em, Camera: player1}
+
+	cameras := rs.cameras()
+	want := []*entity.Entity{player1, player2, hud}
+	if len(cameras) != len(want) {
+		t.Fatalf("%d cameras drawn; want %d", len(cameras), len(want))
+	}
+	for i, camera := range cameras {
+		if camera != want[i] {
+			t.Errorf("camera %d drawn in position %d; want %d", camera.(*entity.Entity).GetID(), i, want[i].GetID())
+		}
+	}
+
+	// the main camera is drawn once, even if it's active
+	player1.GetComponent(&entity.CameraComponent{}).(*entity.CameraComponent).IsActive = true
+	if cameras = rs.cameras(); len(cameras) != len(want) {
+		t.Errorf("%d cameras drawn; want %d", len(cameras), len(want))
+	}
+}
+
+// createSoftwareRenderer creates a render system drawing in a surface with the software renderer, so the tests run
+// without a window. It has a camera seeing the whole surface and a red square of 4x4 pixels at (2, 2)
+func createSoftwareRenderer(t *testing.T, w, h int32) (*RenderSystem, func()) {
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, w, h, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+	if err != nil {
+		t.Skipf("surface could not be created: %v", err)
+	}
+	renderer, err := sdl.CreateSoftwareRenderer(surface)
+	if err != nil {
+		surface.Free()
+		t.Skipf("software renderer could not be created: %v", err)
+	}
+	sprite, err := sdl.CreateRGBSurfaceWithFormat(0, 4, 4, 32, uint32(sdl.PIXELFORMAT_ARGB8888))
+	if err != nil {
+		t.Fatalf("sprite could not be created: %v", err)
+	}
+	sprite.FillRect(nil, 0xFFFF0000)
+	texture, err := renderer.CreateTextureFromSurface(sprite)
+	sprite.Free()
+	if err != nil {
+		t.Fatalf("texture could not be created: %v", err)
+	}
+
+	em := &entity.Manager{}
+	square := em.Create("square")
+	square.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 2, Y: 2}})
+	square.AddComponent(&entity.RenderComponent{RenderType: entity.RTSprite, Texture: texture,
+		Crop: &sdl.Rect{X: 0, Y: 0, W: 4, H: 4}})
+	camera := em.Create("camera")
+	camera.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: 0}})
+	camera.AddComponent(&entity.CameraComponent{ViewportSize: sdl.Point{X: w, Y: h}})
+	rs := &RenderSystem{EntityManager: em, Renderer: renderer, Camera: camera,
+		BgColor: sdl.Color{R: 0xFF, G: 0xFF, B: 0xFF, A: 0xFF}}
+	return rs, func() {
+		texture.Destroy()
+		renderer.Destroy()
+		surface.Free()
+	}
+}
+
+func TestRenderSystem_RenderToTexture(t *testing.T) {
+	rs, destroy := createSoftwareRenderer(t, 16, 16)
+	defer destroy()
+	target, err := rs.CreateRenderTarget(16, 16)
+	if err != nil {
+		t.Fatalf("CreateRenderTarget() returned %v", err)
+	}
+	defer target.Destroy()
+
+	cases := []struct {
+		name   string
+		filter func(obj *entity.Entity) bool
+		want   uint32
+	}{
+		{"all entities", nil, 0xFFFF0000},
+		{"filtered", func(obj *entity.Entity) bool { return false }, 0xFFFFFFFF},
+	}
+	for _, c := range cases {
+		if err := rs.RenderToTexture(target, nil, c.filter); err != nil {
+			t.Fatalf("%s: RenderToTexture() returned %v", c.name, err)
+		}
+		pixels := make([]uint32, 16*16)
+		rs.Renderer.SetRenderTarget(target)
+		err := rs.Renderer.ReadPixels(nil, uint32(sdl.PIXELFORMAT_ARGB8888), unsafe.Pointer(&pixels[0]), 16*4)
+		rs.Renderer.SetRenderTarget(nil)
+		if err != nil {
+			t.Fatalf("%s: ReadPixels() returned %v", c.name, err)
+		}
+		if got := pixels[3*16+3]; got != c.want {
+			t.Errorf("%s: pixel (3, 3) == %#x; want %#x", c.name, got, c.want)
+		}
+		if got := pixels[0]; got != 0xFFFFFFFF {
+			t.Errorf("%s: pixel (0, 0) == %#x; want the background", c.name, got)
+		}
+	}
+}
+
+func TestRenderSystem_Screenshot(t *testing.T) {
+	rs, destroy := createSoftwareRenderer(t, 16, 16)
+	defer destroy()
+	dir, err := ioutil.TempDir("", "macaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "screenshot.png")
+
+	if err := rs.Screenshot(path); err != nil {
+		t.Fatalf("Screenshot() returned %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("screenshot not saved: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("screenshot is not a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 16 || size.Y != 16 {
+		t.Errorf("screenshot size == %v; want 16x16", size)
+	}
+	if r, g, b, _ := img.At(3, 3).RGBA(); r != 0xFFFF || g != 0 || b != 0 {
+		t.Errorf("pixel (3, 3) == (%#x, %#x, %#x); want red", r, g, b)
+	}
+	if r, g, b, _ := img.At(0, 0).RGBA(); r != 0xFFFF || g != 0xFFFF || b != 0xFFFF {
+		t.Errorf("pixel (0, 0) == (%#x, %#x, %#x); want white", r, g, b)
+	}
+}
+
+func TestRenderSystem_ScreenshotSplitScreen(t *testing.T) {
+	rs, destroy := createSoftwareRenderer(t, 16, 16)
+	defer destroy()
+	dir, err := ioutil.TempDir("", "macaw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "screenshot.png")
+	// the main camera uses the left half of the screen and another camera, seeing the same place, the right half
+	main := rs.Camera.GetComponent(&entity.CameraComponent{}).(*entity.CameraComponent)
+	main.Viewport = &sdl.Rect{X: 0, Y: 0, W: 8, H: 16}
+	right := rs.EntityManager.Create("camera")
+	right.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: 0}})
+	right.AddComponent(&entity.CameraComponent{Viewport: &sdl.Rect{X: 8, Y: 0, W: 8, H: 16}, IsActive: true})
+
+	if err := rs.Screenshot(path); err != nil {
+		t.Fatalf("Screenshot() returned %v", err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("screenshot not saved: %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("screenshot is not a PNG: %v", err)
+	}
+	for _, p := range []struct{ x, y int }{{3, 3}, {11, 3}} {
+		if r, g, b, _ := img.At(p.x, p.y).RGBA(); r != 0xFFFF || g != 0 || b != 0 {
+			t.Errorf("pixel (%d, %d) == (%#x, %#x, %#x); want red", p.x, p.y, r, g, b)
+		}
+	}
+}
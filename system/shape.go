@@ -0,0 +1,242 @@
+package system
+
+import (
+	gomath "math"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// convexShape is a collision shape placed in the world. It has a convex core (a point, a segment or a polygon)
+// and a radius around it, so circles and capsules are rounded points and segments
+type convexShape struct {
+	points []math.FPoint
+	radius float32
+}
+
+// collisionShapes returns the collision areas and shapes of the object placed in the world
+func collisionShapes(obj *entity.Entity, position *entity.PositionComponent,
+	collision *entity.CollisionComponent) []convexShape {
+	shapes := make([]convexShape, 0, len(collision.CollisionAreas)+len(collision.Shapes))
+	for _, area := range collision.CollisionAreas {
+		x, y := float32(position.Pos.X+area.X), float32(position.Pos.Y+area.Y)
+		w, h := float32(area.W), float32(area.H)
+		shapes = append(shapes, convexShape{points: []math.FPoint{{X: x, Y: y}, {X: x + w, Y: y},
+			{X: x + w, Y: y + h}, {X: x, Y: y + h}}})
+	}
+	if len(collision.Shapes) == 0 {
+		return shapes
+	}
+	angle, pivot := rotation(obj)
+	origin := math.FPoint{X: float32(position.Pos.X), Y: float32(position.Pos.Y)}
+	for _, shape := range collision.Shapes {
+		shapes = append(shapes, placeShape(shape, origin, pivot, angle))
+	}
+	return shapes
+}
+
+// collisionRects returns the collision areas of the object in the world and the bounding boxes of its shapes
+func collisionRects(obj *entity.Entity, position *entity.PositionComponent,
+	collision *entity.CollisionComponent) []sdl.Rect {
+	rects := make([]sdl.Rect, 0, len(collision.CollisionAreas)+len(collision.Shapes))
+	for _, area := range collision.CollisionAreas {
+		rects = append(rects, sdl.Rect{X: position.Pos.X + area.X, Y: position.Pos.Y + area.Y, W: area.W, H: area.H})
+	}
+	if len(collision.Shapes) == 0 {
+		return rects
+	}
+	for _, shape := range collisionShapes(obj, position, collision)[len(collision.CollisionAreas):] {
+		rects = append(rects, shape.box())
+	}
+	return rects
+}
+
+// rotation returns the angle of the object and the point, relative to its position, it rotates around
+func rotation(obj *entity.Entity) (float64, math.FPoint) {
+	var angle float64
+	var pivot math.FPoint
+	var physics *entity.PhysicsComponent
+	if component := obj.GetComponent(&entity.PhysicsComponent{}); component != nil {
+		physics = component.(*entity.PhysicsComponent)
+		if !physics.FixedRotation {
+			angle = physics.FutureAngle
+		}
+	}
+	if component := obj.GetComponent(&entity.RenderComponent{}); component != nil {
+		render := component.(*entity.RenderComponent)
+		if physics == nil || physics.FixedRotation {
+			angle = render.Angle
+		}
+		// same center used by the render
+		if render.Center != nil {
+			pivot = math.FPoint{X: float32(render.Center.X), Y: float32(render.Center.Y)}
+		} else if render.Crop != nil {
+			pivot = math.FPoint{X: float32(render.Crop.W) / 2, Y: float32(render.Crop.H) / 2}
+		}
+	}
+	return angle, pivot
+}
+
+// placeShape places the shape in the world, rotating it around the pivot
+func placeShape(shape entity.CollisionShape, origin, pivot math.FPoint, angle float64) convexShape {
+	var points []math.FPoint
+	var radius float32
+	o := shape.Offset
+	switch shape.ShapeType {
+	case entity.ShapeCircle:
+		points = []math.FPoint{o}
+		radius = shape.Radius
+	case entity.ShapeCapsule:
+		h := shape.Length / 2
+		points = []math.FPoint{{X: o.X, Y: o.Y - h}, {X: o.X, Y: o.Y + h}}
+		radius = shape.Radius
+	case entity.ShapePolygon:
+		points = make([]math.FPoint, len(shape.Points))
+		for i, p := range shape.Points {
+			points[i] = math.FPoint{X: o.X + p.X, Y: o.Y + p.Y}
+		}
+	case entity.ShapeBox:
+		w, h := shape.Size.X/2, shape.Size.Y/2
+		points = []math.FPoint{{X: o.X - w, Y: o.Y - h}, {X: o.X + w, Y: o.Y - h}, {X: o.X + w, Y: o.Y + h},
+			{X: o.X - w, Y: o.Y + h}}
+	}
+	for i, p := range points {
+		r := math.RotateFPoint(math.FPoint{X: p.X - pivot.X, Y: p.Y - pivot.Y}, angle)
+		points[i] = math.FPoint{X: origin.X + pivot.X + r.X, Y: origin.Y + pivot.Y + r.Y}
+	}
+	return convexShape{points: points, radius: radius}
+}
+
+// shapesCollide checks if the shapes overlap. Shapes only touching each other don't collide
+func shapesCollide(a, b convexShape) bool {
+	if len(a.points) == 0 || len(b.points) == 0 {
+		return false
+	}
+	if a.radius+b.radius == 0 {
+		return len(a.points) > 2 && len(b.points) > 2 && math.PolygonsOverlap(a.points, b.points)
+	}
+	return math.ConvexDistance(a.points, b.points) < a.radius+b.radius
+}
+
+// box returns the bounding box of the shape
+func (s convexShape) box() sdl.Rect {
+	if len(s.points) == 0 {
+		return sdl.Rect{}
+	}
+	min, max := s.points[0], s.points[0]
+	for _, p := range s.points[1:] {
+		if p.X < min.X {
+			min.X = p.X
+		} else if p.X > max.X {
+			max.X = p.X
+		}
+		if p.Y < min.Y {
+			min.Y = p.Y
+		} else if p.Y > max.Y {
+			max.Y = p.Y
+		}
+	}
+	x := int32(gomath.Floor(float64(min.X - s.radius)))
+	y := int32(gomath.Floor(float64(min.Y - s.radius)))
+	w := int32(gomath.Ceil(float64(max.X+s.radius))) - x
+	h := int32(gomath.Ceil(float64(max.Y+s.radius))) - y
+	return sdl.Rect{X: x, Y: y, W: w, H: h}
+}
+
+// circle returns the center and the radius of the shape if it's a circle
+func (s convexShape) circle() (math.FPoint, float32, bool) {
+	if len(s.points) != 1 {
+		return math.FPoint{}, 0, false
+	}
+	return s.points[0], s.radius, true
+}
+
+// rectShape returns the shape of the rectangle
+func rectShape(rect sdl.Rect) convexShape {
+	x, y, w, h := float32(rect.X), float32(rect.Y), float32(rect.W), float32(rect.H)
+	return convexShape{points: []math.FPoint{{X: x, Y: y}, {X: x + w, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}}
+}
+
+// manifold returns the contact between two colliding shapes: the normal, pointing from a to b, how much they
+// penetrate each other along it and the contact points
+func manifold(a, b convexShape) (math.FPoint, float32, []math.FPoint) {
+	radius := a.radius + b.radius
+	if radius > 0 && math.ConvexDistance(a.points, b.points) > 0 {
+		// the cores don't overlap, only the rounded parts. The contact is between the closest points of the cores
+		pa, pb := math.ClosestPoints(a.points, b.points)
+		d := math.FPoint{X: pb.X - pa.X, Y: pb.Y - pa.Y}
+		dist := math.Length(d)
+		normal := math.FPoint{X: d.X / dist, Y: d.Y / dist}
+		depth := radius - dist
+		r := a.radius - depth/2
+		return normal, depth, []math.FPoint{{X: pa.X + normal.X*r, Y: pa.Y + normal.Y*r}}
+	}
+
+	normal, overlap := math.MinimumTranslation(a.points, b.points)
+	var points []math.FPoint
+	if len(b.points) > 2 {
+		for _, p := range a.points {
+			if math.PointInPolygon(p, b.points) {
+				points = append(points, p)
+			}
+		}
+	}
+	if len(a.points) > 2 {
+		for _, p := range b.points {
+			if math.PointInPolygon(p, a.points) {
+				points = append(points, p)
+			}
+		}
+	}
+	if len(points) == 0 {
+		ca, cb := math.Centroid(a.points), math.Centroid(b.points)
+		points = []math.FPoint{{X: (ca.X + cb.X) / 2, Y: (ca.Y + cb.Y) / 2}}
+	}
+	return normal, overlap + radius, points
+}
+
+// sweepPoints returns the contact points of a continuous collision. The first shape moved until the time of
+// impact and both are displaced by the movement of the second one (offset)
+func sweepPoints(a, b convexShape, motion math.FPoint, toi float32, normal, offset math.FPoint) []math.FPoint {
+	moved := math.FPoint{X: motion.X*toi + offset.X, Y: motion.Y*toi + offset.Y}
+	if center, radius, ok := a.circle(); ok {
+		if _, _, ok := b.circle(); ok {
+			return []math.FPoint{{X: center.X + moved.X + normal.X*radius, Y: center.Y + moved.Y + normal.Y*radius}}
+		}
+	}
+	// the boxes touch at the side of the first box facing the normal, where both boxes overlap
+	box1, box2 := a.box(), b.box()
+	x1, y1 := float32(box1.X)+moved.X, float32(box1.Y)+moved.Y
+	x2, y2 := float32(box2.X)+offset.X, float32(box2.Y)+offset.Y
+	if normal.X != 0 {
+		x := x1
+		if normal.X > 0 {
+			x += float32(box1.W)
+		}
+		top, bottom := overlapRange(y1, y1+float32(box1.H), y2, y2+float32(box2.H))
+		return []math.FPoint{{X: x, Y: top}, {X: x, Y: bottom}}
+	}
+	y := y1
+	if normal.Y > 0 {
+		y += float32(box1.H)
+	}
+	left, right := overlapRange(x1, x1+float32(box1.W), x2, x2+float32(box2.W))
+	return []math.FPoint{{X: left, Y: y}, {X: right, Y: y}}
+}
+
+// overlapRange returns the common part of the intervals [min1, max1] and [min2, max2]
+func overlapRange(min1, max1, min2, max2 float32) (float32, float32) {
+	if min2 > min1 {
+		min1 = min2
+	}
+	if max2 < max1 {
+		max1 = max2
+	}
+	if max1 < min1 {
+		// they don't overlap (corner hit), so the contact is in between
+		min1 = (min1 + max1) / 2
+		max1 = min1
+	}
+	return min1, max1
+}
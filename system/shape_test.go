@@ -0,0 +1,105 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCollisionSystem_Shapes(t *testing.T) {
+	circle := entity.CollisionShape{ShapeType: entity.ShapeCircle, Radius: 10}
+	capsule := entity.CollisionShape{ShapeType: entity.ShapeCapsule, Radius: 5, Length: 40}
+	box := entity.CollisionShape{ShapeType: entity.ShapeBox, Size: math.FPoint{X: 40, Y: 4}}
+	triangle := entity.CollisionShape{ShapeType: entity.ShapePolygon,
+		Points: []math.FPoint{{X: 0, Y: 0}, {X: 20, Y: 0}, {X: 0, Y: 20}}}
+	cases := []struct {
+		name   string
+		shape1 entity.CollisionShape
+		pos1   sdl.Point
+		angle  float64 // render angle of the first object
+		shape2 entity.CollisionShape
+		pos2   sdl.Point
+		want   bool
+	}{
+		{"circles", circle, sdl.Point{X: 0, Y: 0}, 0, circle, sdl.Point{X: 15, Y: 10}, true},
+		{"circles apart", circle, sdl.Point{X: 0, Y: 0}, 0, circle, sdl.Point{X: 15, Y: 15}, false},
+		// the bounding boxes overlap, but not the circle and the hypotenuse of the triangle
+		{"circle and triangle", circle, sdl.Point{X: 18, Y: 18}, 0, triangle, sdl.Point{X: 0, Y: 0}, false},
+		{"circle and capsule", circle, sdl.Point{X: 12, Y: 18}, 0, capsule, sdl.Point{X: 0, Y: 0}, true},
+		{"capsule end", circle, sdl.Point{X: 12, Y: 30}, 0, capsule, sdl.Point{X: 0, Y: 0}, false},
+		{"horizontal box", box, sdl.Point{X: 0, Y: 0}, 0, circle, sdl.Point{X: 0, Y: 15}, false},
+		{"rotated box", box, sdl.Point{X: 0, Y: 0}, 90, circle, sdl.Point{X: 0, Y: 15}, true},
+		{"rotated triangle", triangle, sdl.Point{X: 0, Y: 0}, 180, circle, sdl.Point{X: -5, Y: -5}, true},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		obj1 := createCollider(em, c.pos1.X, c.pos1.Y,
+			&entity.CollisionComponent{Shapes: []entity.CollisionShape{c.shape1}})
+		obj1.AddComponent(&entity.RenderComponent{Angle: c.angle})
+		obj2 := createCollider(em, c.pos2.X, c.pos2.Y,
+			&entity.CollisionComponent{Shapes: []entity.CollisionShape{c.shape2}})
+
+		var events []*CollisionEvent
+		cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+		cs.AddHandler("collision event", func(e Event) {
+			events = append(events, e.(*CollisionEvent))
+		})
+		cs.Update()
+
+		if got := len(events) > 0; got != c.want {
+			t.Errorf("%s: collision == %v; want %v", c.name, got, c.want)
+		}
+		if c.want && (events[0].Ent != obj1 || events[0].With != obj2) {
+			t.Errorf("%s: wrong event %v", c.name, events[0])
+		}
+	}
+}
+
+func TestCollisionSystem_ShapesAndAreas(t *testing.T) {
+	em := &entity.Manager{}
+	// rotated by the physics around the center of the sprite
+	ball := createCollider(em, 0, 0, &entity.CollisionComponent{Shapes: []entity.CollisionShape{
+		{ShapeType: entity.ShapeCircle, Offset: math.FPoint{X: 5}, Radius: 5}}})
+	ball.AddComponent(&entity.RenderComponent{Crop: &sdl.Rect{X: 0, Y: 0, W: 20, H: 20}})
+	ball.AddComponent(&entity.PhysicsComponent{FutureAngle: 180})
+	createCollider(em, 16, 14, &entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: 10, H: 10}}})
+
+	collisions := 0
+	cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+	cs.AddHandler("collision event", func(e Event) {
+		collisions++
+	})
+	cs.Update()
+	if collisions != 2 {
+		t.Errorf("%d collision events; want 2", collisions)
+	}
+}
+
+func TestCollisionSystem_ContinuousCircles(t *testing.T) {
+	em := &entity.Manager{}
+	ball := createCollider(em, 200, 0, &entity.CollisionComponent{Continuous: true,
+		Shapes: []entity.CollisionShape{{ShapeType: entity.ShapeCircle, Radius: 5}}})
+	physics := &entity.PhysicsComponent{PrevPos: &math.FPoint{X: 0, Y: 0}, FuturePos: &math.FPoint{X: 200, Y: 0},
+		Vel: &math.FPoint{X: 10000}}
+	ball.AddComponent(physics)
+	createCollider(em, 100, 8,
+		&entity.CollisionComponent{Shapes: []entity.CollisionShape{{ShapeType: entity.ShapeCircle, Radius: 5}}})
+
+	var events []*CollisionEvent
+	cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+	cs.AddHandler("collision event", func(e Event) {
+		events = append(events, e.(*CollisionEvent))
+	})
+	cs.Update()
+
+	if len(events) != 2 {
+		t.Fatalf("%d events; want 2", len(events))
+	}
+	// the circles touch when the distance between the centers is 10
+	if physics.FuturePos.X != 94 || events[0].Normal.Y <= 0 {
+		t.Errorf("FuturePos == %v, Normal == %v; want x=94 and a normal pointing down", physics.FuturePos,
+			events[0].Normal)
+	}
+}
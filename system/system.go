@@ -8,10 +8,14 @@ import (
 )
 
 const (
-	// If you want your game to be faster increase this number.
-	ticksPerSecond = 50
-	// UpdateTickLength is the length per update. The game will be updated at a steady 50 times per second,
-	UpdateTickLength = 1000 / ticksPerSecond
+	// DefaultTicksPerSecond is the update rate used when none is configured.
+	DefaultTicksPerSecond = 50
+)
+
+var (
+	// UpdateTickLength is the length (in milliseconds) per update. By default the game will be updated
+	// at a steady 50 times per second. Use SetTicksPerSecond to change it.
+	UpdateTickLength uint32 = 1000 / DefaultTicksPerSecond
 )
 
 var (
@@ -19,6 +23,23 @@ var (
 	logFatalf = utils.LogFatal // replace for variable so we can change in the test
 )
 
+// SetTicksPerSecond changes how many times per second the update systems run.
+// Changing the tick rate doesn't change the game speed, since the systems use DeltaTime.
+// If ticks is 0 the default rate is used. The maximum rate is one update per millisecond.
+func SetTicksPerSecond(ticks uint32) {
+	if ticks == 0 {
+		ticks = DefaultTicksPerSecond
+	} else if ticks > 1000 {
+		ticks = 1000
+	}
+	UpdateTickLength = 1000 / ticks
+}
+
+// DeltaTime returns the fixed timestep, in seconds, between two updates
+func DeltaTime() float32 {
+	return float32(UpdateTickLength) / 1000
+}
+
 // Systemer is the interface containing behaviours that every system should have
 type Systemer interface {
 	// Update will be run in the game loop whenever possible
@@ -0,0 +1,325 @@
+package system
+
+import (
+	gomath "math"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	sideLeft = 1 << iota
+	sideRight
+	sideTop
+	sideBottom
+)
+
+// tileMap is an entity with a grid of tiles
+type tileMap struct {
+	obj      *entity.Entity
+	position *entity.PositionComponent
+	tiles    *entity.TileCollisionComponent
+}
+
+// TileContact is the tile of a tile map hit in a collision. Type is the type of the tile (e.g. entity.TileFull)
+type TileContact struct {
+	Col  int
+	Row  int
+	Type int
+}
+
+// checkTileCollision checks the collider against the tiles under its collision areas and shapes.
+// A "tile collision" event is notified for each tile hit
+func (c *CollisionSystem) checkTileCollision(col collider, m tileMap) {
+	size := m.tiles.TileSize
+	if size.X <= 0 || size.Y <= 0 {
+		return
+	}
+	origin := m.position.Pos
+	start, _ := motion(col.obj, col.position)
+	dy := float32(start.Y - col.position.Pos.Y)
+	for i, shape := range collisionShapes(col.obj, col.position, col.collision) {
+		box := shape.box()
+		// only the tiles under the box are checked
+		firstCol, lastCol := floorDiv(box.X-origin.X, size.X), floorDiv(box.X+box.W-1-origin.X, size.X)
+		firstRow, lastRow := floorDiv(box.Y-origin.Y, size.Y), floorDiv(box.Y+box.H-1-origin.Y, size.Y)
+		for row := firstRow; row <= lastRow; row++ {
+			for tc := firstCol; tc <= lastCol; tc++ {
+				tile := m.tiles.Tile(tc, row)
+				if tile == entity.TileEmpty {
+					continue
+				}
+				rect := m.tileRect(tc, row)
+				var normal math.FPoint
+				var depth float32
+				var points []math.FPoint
+				if tile == entity.TileOneWay {
+					// it blocks only if the collider was above the tile in the previous tick
+					top, bottom := float32(rect.Y), float32(box.Y+box.H)
+					if bottom <= top || bottom+dy > top {
+						continue
+					}
+					left, right := overlapRange(float32(box.X), float32(box.X+box.W), float32(rect.X),
+						float32(rect.X+rect.W))
+					normal, depth = math.FPoint{X: 0, Y: 1}, bottom-top
+					points = []math.FPoint{{X: left, Y: top}, {X: right, Y: top}}
+				} else {
+					tileShape := m.tileShape(rect, tile)
+					if !shapesCollide(shape, tileShape) {
+						continue
+					}
+					normal, depth, points = manifold(shape, tileShape)
+					var ok bool
+					if normal, depth, ok = m.exposedContact(tc, row, box, normal, depth); !ok {
+						continue
+					}
+				}
+				c.NotifyEvent(&CollisionEvent{Ent: col.obj, With: m.obj, Normal: normal, Depth: depth,
+					Points: points, Area: i, Tile: &TileContact{Col: tc, Row: row, Type: tile}})
+			}
+		}
+	}
+}
+
+// tileRect returns the rectangle of the tile in the world
+func (m tileMap) tileRect(col, row int) sdl.Rect {
+	size := m.tiles.TileSize
+	return sdl.Rect{X: m.position.Pos.X + int32(col)*size.X, Y: m.position.Pos.Y + int32(row)*size.Y,
+		W: size.X, H: size.Y}
+}
+
+// tileShape returns the solid part of the tile
+func (m tileMap) tileShape(rect sdl.Rect, tile int) convexShape {
+	x, y, w, h := float32(rect.X), float32(rect.Y), float32(rect.W), float32(rect.H)
+	switch tile {
+	case entity.TileSlopeUp:
+		return convexShape{points: []math.FPoint{{X: x, Y: y + h}, {X: x + w, Y: y}, {X: x + w, Y: y + h}}}
+	case entity.TileSlopeDown:
+		return convexShape{points: []math.FPoint{{X: x, Y: y}, {X: x + w, Y: y + h}, {X: x, Y: y + h}}}
+	}
+	return rectShape(rect)
+}
+
+// exposedContact avoids contacts with the sides of the tile covered by the neighbor tile (internal edges). They
+// would stop the colliders moving along a row of tiles at the seams. The contact is moved to the other axis or,
+// if that side is also covered, it's ignored
+func (m tileMap) exposedContact(col, row int, box sdl.Rect, normal math.FPoint,
+	depth float32) (math.FPoint, float32, bool) {
+	if !m.covered(col, row, normal) {
+		return normal, depth, true
+	}
+	if m.tiles.Tile(col, row) != entity.TileFull {
+		return normal, depth, false
+	}
+	rect := m.tileRect(col, row)
+	if normal.X != 0 {
+		down, up := box.Y+box.H-rect.Y, rect.Y+rect.H-box.Y
+		if down < up {
+			normal, depth = math.FPoint{X: 0, Y: 1}, float32(down)
+		} else {
+			normal, depth = math.FPoint{X: 0, Y: -1}, float32(up)
+		}
+	} else {
+		right, left := box.X+box.W-rect.X, rect.X+rect.W-box.X
+		if right < left {
+			normal, depth = math.FPoint{X: 1, Y: 0}, float32(right)
+		} else {
+			normal, depth = math.FPoint{X: -1, Y: 0}, float32(left)
+		}
+	}
+	return normal, depth, !m.covered(col, row, normal)
+}
+
+// covered checks if the side of the tile hit by the contact is covered by the neighbor tile.
+// The normal points from the collider to the tile. Only axis aligned normals hit a side
+func (m tileMap) covered(col, row int, normal math.FPoint) bool {
+	var side, opposite, dc, dr int
+	switch {
+	case normal.Y == 0 && normal.X > 0:
+		side, opposite, dc = sideLeft, sideRight, -1
+	case normal.Y == 0 && normal.X < 0:
+		side, opposite, dc = sideRight, sideLeft, 1
+	case normal.X == 0 && normal.Y > 0:
+		side, opposite, dr = sideTop, sideBottom, -1
+	case normal.X == 0 && normal.Y < 0:
+		side, opposite, dr = sideBottom, sideTop, 1
+	default:
+		return false
+	}
+	return tileSides(m.tiles.Tile(col, row))&side != 0 && tileSides(m.tiles.Tile(col+dc, row+dr))&opposite != 0
+}
+
+// tileSides returns the sides fully covered by the tile
+func tileSides(tile int) int {
+	switch tile {
+	case entity.TileFull:
+		return sideLeft | sideRight | sideTop | sideBottom
+	case entity.TileSlopeUp:
+		return sideRight | sideBottom
+	case entity.TileSlopeDown:
+		return sideLeft | sideBottom
+	}
+	return 0
+}
+
+// floorDiv divides rounding down, so negative coordinates are in the right tile
+func floorDiv(a, b int32) int {
+	return int(gomath.Floor(float64(a) / float64(b)))
+}
+
+// bounds returns the rectangle covered by the grid in the world
+func (m tileMap) bounds() sdl.Rect {
+	cols := 0
+	for _, row := range m.tiles.Tiles {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	size := m.tiles.TileSize
+	return sdl.Rect{X: m.position.Pos.X, Y: m.position.Pos.Y, W: int32(cols) * size.X,
+		H: int32(len(m.tiles.Tiles)) * size.Y}
+}
+
+// raycast returns the first tile hit by the ray. Only the cells crossed by the ray are checked, walking the grid
+// from the cell where the ray enters it (DDA). The direction must be normalized
+func (m tileMap) raycast(origin, direction math.FPoint, maxDistance float32) (RaycastHit, bool) {
+	size := m.tiles.TileSize
+	if size.X <= 0 || size.Y <= 0 {
+		return RaycastHit{}, false
+	}
+	grid := m.bounds()
+	if grid.W == 0 || grid.H == 0 {
+		return RaycastHit{}, false
+	}
+	enter, exit, ok := raySlab(origin, direction, grid)
+	if !ok || enter > maxDistance {
+		return RaycastHit{}, false
+	}
+	if exit > maxDistance {
+		exit = maxDistance
+	}
+	// the cell where the ray enters the grid, relative to the grid
+	w, h := float32(size.X), float32(size.Y)
+	x := origin.X + direction.X*enter - float32(grid.X)
+	y := origin.Y + direction.Y*enter - float32(grid.Y)
+	col := clampInt(int(gomath.Floor(float64(x/w))), 0, int(grid.W/size.X)-1)
+	row := clampInt(int(gomath.Floor(float64(y/h))), 0, int(grid.H/size.Y)-1)
+	stepCol, nextCol, deltaCol := ddaAxis(x, direction.X, w, col, enter)
+	stepRow, nextRow, deltaRow := ddaAxis(y, direction.Y, h, row, enter)
+	for t := enter; t <= exit; {
+		if tile := m.tiles.Tile(col, row); tile != entity.TileEmpty {
+			if d, normal, ok := m.raycastTile(col, row, tile, origin, direction); ok && d <= maxDistance {
+				point := math.FPoint{X: origin.X + direction.X*d, Y: origin.Y + direction.Y*d}
+				return RaycastHit{Ent: m.obj, Point: point, Normal: normal, Distance: d,
+					Tile: &TileContact{Col: col, Row: row, Type: tile}}, true
+			}
+		}
+		if nextCol < nextRow {
+			t, nextCol, col = nextCol, nextCol+deltaCol, col+stepCol
+		} else {
+			t, nextRow, row = nextRow, nextRow+deltaRow, row+stepRow
+		}
+	}
+	return RaycastHit{}, false
+}
+
+// raycastTile returns where the ray hits the solid part of the tile, like raycastShape.
+// One-way tiles are only hit from above
+func (m tileMap) raycastTile(col, row, tile int, origin, direction math.FPoint) (float32, math.FPoint, bool) {
+	rect := m.tileRect(col, row)
+	if tile != entity.TileOneWay {
+		return raycastShape(m.tileShape(rect, tile), origin, direction)
+	}
+	top := float32(rect.Y)
+	if direction.Y <= 0 || origin.Y > top {
+		return 0, math.FPoint{}, false
+	}
+	t, ok := math.RaySegment(origin, direction, math.FPoint{X: float32(rect.X), Y: top},
+		math.FPoint{X: float32(rect.X + rect.W), Y: top})
+	return t, math.FPoint{X: 0, Y: -1}, ok
+}
+
+// overlaps checks if the shape overlaps a solid tile. One-way tiles are solid
+func (m tileMap) overlaps(shape convexShape) bool {
+	size := m.tiles.TileSize
+	if size.X <= 0 || size.Y <= 0 {
+		return false
+	}
+	box, origin := shape.box(), m.position.Pos
+	firstCol, lastCol := floorDiv(box.X-origin.X, size.X), floorDiv(box.X+box.W-1-origin.X, size.X)
+	firstRow, lastRow := floorDiv(box.Y-origin.Y, size.Y), floorDiv(box.Y+box.H-1-origin.Y, size.Y)
+	for row := firstRow; row <= lastRow; row++ {
+		for col := firstCol; col <= lastCol; col++ {
+			tile := m.tiles.Tile(col, row)
+			if tile != entity.TileEmpty && shapesCollide(shape, m.tileShape(m.tileRect(col, row), tile)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// containsPoint checks if the point is inside a solid tile. One-way tiles are solid
+func (m tileMap) containsPoint(p math.FPoint) bool {
+	size := m.tiles.TileSize
+	if size.X <= 0 || size.Y <= 0 {
+		return false
+	}
+	col := int(gomath.Floor(float64((p.X - float32(m.position.Pos.X)) / float32(size.X))))
+	row := int(gomath.Floor(float64((p.Y - float32(m.position.Pos.Y)) / float32(size.Y))))
+	tile := m.tiles.Tile(col, row)
+	return tile != entity.TileEmpty && containsPoint(m.tileShape(m.tileRect(col, row), tile), p)
+}
+
+// ddaAxis returns, for one axis of the ray, the step between cells, the distance where the ray crosses to the
+// next cell and the distance between crossings. The position is relative to the grid at the distance t
+func ddaAxis(position, direction, size float32, cell int, t float32) (int, float32, float32) {
+	switch {
+	case direction > 0:
+		return 1, t + (float32(cell+1)*size-position)/direction, size / direction
+	case direction < 0:
+		return -1, t + (float32(cell)*size-position)/direction, -size / direction
+	}
+	return 0, gomath.MaxFloat32, 0
+}
+
+// raySlab returns the distances where the ray enters and exits the rectangle. The entry is 0 if the origin is
+// inside it
+func raySlab(origin, direction math.FPoint, rect sdl.Rect) (float32, float32, bool) {
+	enter, exit := float32(0), float32(gomath.MaxFloat32)
+	axes := []struct{ o, d, min, max float32 }{
+		{origin.X, direction.X, float32(rect.X), float32(rect.X + rect.W)},
+		{origin.Y, direction.Y, float32(rect.Y), float32(rect.Y + rect.H)},
+	}
+	for _, a := range axes {
+		if a.d == 0 {
+			if a.o < a.min || a.o > a.max {
+				return 0, 0, false
+			}
+			continue
+		}
+		t1, t2 := (a.min-a.o)/a.d, (a.max-a.o)/a.d
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > enter {
+			enter = t1
+		}
+		if t2 < exit {
+			exit = t2
+		}
+	}
+	return enter, exit, enter <= exit
+}
+
+// clampInt limits the value to the range [min, max]
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
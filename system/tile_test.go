@@ -0,0 +1,69 @@
+package system
+
+import (
+	"testing"
+
+	"github.com/tubelz/macaw/entity"
+	"github.com/tubelz/macaw/math"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestCollisionSystem_Tiles(t *testing.T) {
+	tiles := [][]int{
+		{entity.TileEmpty, entity.TileEmpty, entity.TileEmpty, entity.TileEmpty},
+		{entity.TileFull, entity.TileFull, entity.TileSlopeUp, entity.TileOneWay},
+	}
+	cases := []struct {
+		name       string
+		pos, prev  sdl.Point
+		size       int32
+		wantTiles  []TileContact
+		wantNormal math.FPoint // the normal of the events
+	}{
+		// the collider is in the first tile. Its small overlap with the second tile must not push it to the left
+		{"seam", sdl.Point{X: 1, Y: 5}, sdl.Point{X: 1, Y: 5}, 10,
+			[]TileContact{{0, 1, entity.TileFull}, {1, 1, entity.TileFull}}, math.FPoint{X: 0, Y: 1}},
+		{"slope", sdl.Point{X: 26, Y: 8}, sdl.Point{X: 26, Y: 8}, 4,
+			[]TileContact{{2, 1, entity.TileSlopeUp}}, math.FPoint{X: 0.7071, Y: 0.7071}},
+		{"slope above", sdl.Point{X: 21, Y: 8}, sdl.Point{X: 21, Y: 8}, 4, nil, math.FPoint{}},
+		{"one way from above", sdl.Point{X: 32, Y: 8}, sdl.Point{X: 32, Y: 5}, 4,
+			[]TileContact{{3, 1, entity.TileOneWay}}, math.FPoint{X: 0, Y: 1}},
+		{"one way from below", sdl.Point{X: 32, Y: 8}, sdl.Point{X: 32, Y: 14}, 4, nil, math.FPoint{}},
+	}
+	for _, c := range cases {
+		em := &entity.Manager{}
+		level := em.Create("level")
+		level.AddComponent(&entity.PositionComponent{Pos: &sdl.Point{X: 0, Y: 0}})
+		level.AddComponent(&entity.TileCollisionComponent{TileSize: sdl.Point{X: 10, Y: 10}, Tiles: tiles})
+		obj := createCollider(em, c.pos.X, c.pos.Y,
+			&entity.CollisionComponent{CollisionAreas: []sdl.Rect{{X: 0, Y: 0, W: c.size, H: c.size}}})
+		obj.AddComponent(&entity.PhysicsComponent{
+			PrevPos:   &math.FPoint{X: float32(c.prev.X), Y: float32(c.prev.Y)},
+			FuturePos: &math.FPoint{X: float32(c.pos.X), Y: float32(c.pos.Y)},
+		})
+
+		var events []*CollisionEvent
+		cs := &CollisionSystem{EntityManager: em, BorderMode: BorderDisabled}
+		cs.AddHandler("tile collision", func(e Event) {
+			events = append(events, e.(*CollisionEvent))
+		})
+		cs.Update()
+
+		if len(events) != len(c.wantTiles) {
+			t.Errorf("%s: %d events; want %d", c.name, len(events), len(c.wantTiles))
+			continue
+		}
+		for i, event := range events {
+			if event.Ent != obj || event.With != level || *event.Tile != c.wantTiles[i] {
+				t.Errorf("%s: event %d == %+v; want tile %v", c.name, i, event, c.wantTiles[i])
+			}
+			diff := math.FPoint{X: event.Normal.X - c.wantNormal.X, Y: event.Normal.Y - c.wantNormal.Y}
+			if math.Length(diff) > 0.001 {
+				t.Errorf("%s: event %d normal == %v; want %v", c.name, i, event.Normal, c.wantNormal)
+			}
+			if event.Depth <= 0 {
+				t.Errorf("%s: event %d depth == %f; want > 0", c.name, i, event.Depth)
+			}
+		}
+	}
+}